@@ -1,11 +1,13 @@
 package auth
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
@@ -45,11 +47,44 @@ func NewAuthenticationMiddleware() (mux.MiddlewareFunc, AuthenticationContext) {
 	return middlewareFunc, context
 }
 
+// protocolKey is the key of the accepted WebSocket subprotocol in the context of a request
+type protocolKey struct{}
+
+// session returns the user whose session the request belongs to, and the WebSocket subprotocol that carried the session token
+// Browsers cannot set headers on WebSocket requests, so a WebSocket passes the token as one of its subprotocols instead
+func (context *authenticationContext) session(r *http.Request) (user, protocol string, found bool) {
+	if token := r.Header.Get("X-Session-Token"); token != "" {
+		user, found = context.authenticationSessions[token]
+		return user, "", found
+	}
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		return "", "", false
+	}
+	for _, value := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, protocol := range strings.Split(value, ",") {
+			protocol = strings.TrimSpace(protocol)
+			if user, found := context.authenticationSessions[protocol]; found {
+				return user, protocol, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// WebSocketProtocol returns the subprotocol that carried the session token of a WebSocket request
+// It is the only one of the subprotocols offered by the browser that the WebSocket should accept
+func WebSocketProtocol(r *http.Request) string {
+	protocol, _ := r.Context().Value(protocolKey{}).(string)
+	return protocol
+}
+
 func (amw authenticationMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	token := r.Header.Get("X-Session-Token")
 	log.Printf(r.Method + " " + r.RequestURI)
-	if user, found := amw.context.authenticationSessions[token]; found {
+	if user, protocol, found := amw.context.session(r); found {
 		log.Printf("Authenticated user %s\n", user)
+		if protocol != "" {
+			r = r.WithContext(context.WithValue(r.Context(), protocolKey{}, protocol))
+		}
 		amw.next.ServeHTTP(w, r)
 	} else if _, found := amw.context.excludedOperations[r.Method+" "+r.RequestURI]; found {
 		log.Printf("No auth required")
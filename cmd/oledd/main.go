@@ -3,8 +3,15 @@ package main
 import (
 	"bufio"
 	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
 	"log"
+	"math"
+	"mime"
 	"net/http"
+	"net/url"
 	"os"
 	"runtime"
 	"strconv"
@@ -14,51 +21,107 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/samarkin/screen-server/auth"
 	"github.com/samarkin/screen-server/engine"
+	"github.com/samarkin/screen-server/oled"
 )
 
 const PASSWD_FILE_NAME = "./passwd"
 
 // Health contains information about the server
 type Health struct {
-	OS           string `json:"os"`
-	Status       string `json:"status"`
-	ErrorMessage string `json:"errorMessage"`
+	OS                string `json:"os"`
+	Status            string `json:"status"`
+	Device            string `json:"device,omitempty"`
+	ErrorMessage      string `json:"errorMessage"`
+	ReconnectAttempts int    `json:"reconnectAttempts"`
+	LastError         string `json:"lastError,omitempty"`
 }
 
 func handleGetHealth(w http.ResponseWriter, r *http.Request) {
 	h := Health{
 		OS: runtime.GOOS,
 	}
-	e, err := engine.GetEngine()
-	if e.Connected() {
+	e, _ := engine.GetEngine()
+	connection := e.Connection()
+	h.ReconnectAttempts = connection.Attempts
+	if connection.LastError != nil {
+		h.LastError = connection.LastError.Error()
+	}
+	if connection.Connected {
 		h.Status = "connected"
+		h.Device = e.Device()
 	} else {
 		h.Status = "error"
-		h.ErrorMessage = err.Error()
+		if connection.Reconnecting {
+			h.Status = "reconnecting"
+		}
+		h.ErrorMessage = h.LastError
 	}
 	json.NewEncoder(w).Encode(h)
 }
 
 // MessageInfo contains information about a displayed message
 type MessageInfo struct {
-	Line int    `json:"line"`
-	Text string `json:"text"`
+	Line      int    `json:"line"`
+	Text      string `json:"text"`
+	Lines     int    `json:"lines"`
+	CoveredBy *int   `json:"coveredBy,omitempty"`
+}
+
+// messageInfo describes the given line, a line covered by a taller message above reports the line of that message
+func messageInfo(e engine.Engine, line int) MessageInfo {
+	m := e.GetMessage(line)
+	if m.Line != line {
+		return MessageInfo{Line: line, CoveredBy: &m.Line}
+	}
+	return MessageInfo{Line: line, Text: m.Text, Lines: m.Lines}
 }
 
 func handleGetMessages(w http.ResponseWriter, r *http.Request) {
 	e, _ := engine.GetEngine()
-	var response [8]MessageInfo
-	for i := 0; i < 8; i++ {
-		response[i].Line = i
-		response[i].Text = e.GetMessage(i)
+	response := make([]MessageInfo, e.Lines())
+	for i := range response {
+		response[i] = messageInfo(e, i)
 	}
 	json.NewEncoder(w).Encode(response)
 }
 
 // Message contains the text to display
 type Message struct {
-	Text     string `json:"text"`
-	Duration *int   `json:"duration"`
+	Text         string `json:"text"`
+	Duration     *int   `json:"duration"`
+	Font         string `json:"font"`
+	Proportional bool   `json:"proportional"`
+	Scale        int    `json:"scale"`
+	Marquee      string `json:"marquee"`
+	Speed        int    `json:"speed"`
+}
+
+// style validates the display options of the message
+func (msg *Message) style() (oled.TextStyle, error) {
+	style := oled.TextStyle{Font: msg.Font, Proportional: msg.Proportional, Scale: msg.Scale}
+	if _, err := oled.MeasureText("", style); err != nil {
+		return oled.TextStyle{}, err
+	}
+	return style, nil
+}
+
+// marquee validates the scrolling options of the message, there is no marquee when the message does not scroll
+func (msg *Message) marquee() (*engine.Marquee, error) {
+	if msg.Speed < 0 || msg.Speed > 500 {
+		return nil, fmt.Errorf("Speed should be between 1 and 500")
+	}
+	switch msg.Marquee {
+	case "":
+		if msg.Speed != 0 {
+			return nil, fmt.Errorf("Speed is only applicable to a marquee")
+		}
+		return nil, nil
+	case "loop":
+		return &engine.Marquee{Speed: msg.Speed, Loop: true}, nil
+	case "once":
+		return &engine.Marquee{Speed: msg.Speed}, nil
+	}
+	return nil, fmt.Errorf("Marquee should be loop or once")
 }
 
 func handlePostMessage(w http.ResponseWriter, r *http.Request) {
@@ -72,13 +135,90 @@ func handlePostMessage(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Duration is not applicable here", http.StatusBadRequest)
 		return
 	}
+	if msg.Marquee != "" {
+		http.Error(w, "Marquee is not applicable here", http.StatusBadRequest)
+		return
+	}
+	style, err := msg.style()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	e, _ := engine.GetEngine()
-	e.AppendMessage(msg.Text)
+	e.AppendMessage(msg.Text, style)
 }
 
-func handlePostPngImage(w http.ResponseWriter, r *http.Request) {
-	e, _ := engine.GetEngine()
+// imageStyle reads the placement and dithering options of an image from the query string
+func imageStyle(query url.Values) (oled.ImageStyle, error) {
+	var style oled.ImageStyle
 	var err error
+	if dither := query.Get("dither"); dither != "" {
+		if style.Dither, err = oled.DitherByName(dither); err != nil {
+			return style, err
+		}
+	}
+	if cutoff := query.Get("cutoff"); cutoff != "" {
+		if style.Cutoff, err = strconv.Atoi(cutoff); err != nil || style.Cutoff < 1 || style.Cutoff > 255 {
+			return style, fmt.Errorf("Invalid cutoff")
+		}
+	}
+	if gamma := query.Get("gamma"); gamma != "" {
+		if style.Gamma, err = strconv.ParseFloat(gamma, 64); err != nil || !(style.Gamma > 0) || math.IsInf(style.Gamma, 0) {
+			return style, fmt.Errorf("Invalid gamma")
+		}
+	}
+	if placement := query.Get("placement"); placement != "" {
+		if style.Placement, err = oled.PlacementByName(placement); err != nil {
+			return style, err
+		}
+	}
+	if x := query.Get("x"); x != "" {
+		if style.X, err = strconv.Atoi(x); err != nil {
+			return style, fmt.Errorf("Invalid x")
+		}
+	}
+	if y := query.Get("y"); y != "" {
+		if style.Y, err = strconv.Atoi(y); err != nil {
+			return style, fmt.Errorf("Invalid y")
+		}
+	}
+	return style, nil
+}
+
+// imageContentTypes maps the media types of images to the names of their formats
+var imageContentTypes = map[string]string{
+	"image/png":                "png",
+	"image/gif":                "gif",
+	"image/jpeg":               "jpeg",
+	"image/bmp":                "bmp",
+	"image/x-bmp":              "bmp",
+	"image/x-ms-bmp":           "bmp",
+	"image/x-portable-bitmap":  "pbm",
+	"image/x-portable-graymap": "pgm",
+	"image/x-xbitmap":          "xbm",
+}
+
+// imageFormat returns the image format given in the route, or else in the Content-Type header
+// The format is left empty to be detected from the image itself when neither tells it
+func imageFormat(r *http.Request) string {
+	if format, found := mux.Vars(r)["format"]; found {
+		return format
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return imageContentTypes[mediaType]
+}
+
+func handlePostImage(w http.ResponseWriter, r *http.Request) {
+	e, _ := engine.GetEngine()
+	style, err := imageStyle(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	style.Format = imageFormat(r)
 	durationString := r.URL.Query().Get("duration")
 	if len(durationString) > 0 {
 		var duration int64
@@ -86,9 +226,9 @@ func handlePostPngImage(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Invalid duration", http.StatusBadRequest)
 			return
 		}
-		err = e.DisplayTemporaryImage(r.Body, time.Duration(duration)*time.Second)
+		err = e.DisplayTemporaryImage(r.Body, style, time.Duration(duration)*time.Second)
 	} else {
-		err = e.DisplayImage(r.Body)
+		err = e.DisplayImage(r.Body, style)
 	}
 	if err != nil {
 		log.Printf("Unable to display image: %s", err)
@@ -97,6 +237,281 @@ func handlePostPngImage(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func handlePostAnimation(w http.ResponseWriter, r *http.Request) {
+	style, err := imageStyle(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	style.Format = imageFormat(r)
+	var loops, duration int
+	if loopsString := r.URL.Query().Get("loops"); loopsString != "" {
+		if loops, err = strconv.Atoi(loopsString); err != nil || loops < 0 {
+			http.Error(w, "Invalid loops", http.StatusBadRequest)
+			return
+		}
+	}
+	if durationString := r.URL.Query().Get("duration"); durationString != "" {
+		if duration, err = strconv.Atoi(durationString); err != nil || duration < 1 {
+			http.Error(w, "Invalid duration", http.StatusBadRequest)
+			return
+		}
+	}
+	e, _ := engine.GetEngine()
+	if err := e.PlayAnimation(r.Body, style, loops, time.Duration(duration)*time.Second); err != nil {
+		log.Printf("Unable to play animation: %s", err)
+		http.Error(w, "Unable to play the provided animation", http.StatusBadRequest)
+	}
+}
+
+func handleDeleteAnimation(w http.ResponseWriter, r *http.Request) {
+	e, _ := engine.GetEngine()
+	e.StopAnimation()
+}
+
+// DisplayInfo contains the settings of the panel
+type DisplayInfo struct {
+	Contrast int  `json:"contrast"`
+	Inverted bool `json:"inverted"`
+	On       bool `json:"on"`
+}
+
+// Display contains the panel settings to change, the settings that are not given are kept
+type Display struct {
+	Contrast *int  `json:"contrast"`
+	Inverted *bool `json:"inverted"`
+	On       *bool `json:"on"`
+	Duration *int  `json:"duration"`
+}
+
+func handleGetDisplay(w http.ResponseWriter, r *http.Request) {
+	e, _ := engine.GetEngine()
+	settings := e.GetDisplay()
+	json.NewEncoder(w).Encode(DisplayInfo{settings.Contrast, settings.Inverted, settings.On})
+}
+
+func handlePutDisplay(w http.ResponseWriter, r *http.Request) {
+	decoder := json.NewDecoder(r.Body)
+	var display Display
+	if err := decoder.Decode(&display); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	var err error
+	e, _ := engine.GetEngine()
+	settings := e.GetDisplay()
+	if display.Contrast != nil {
+		if *display.Contrast < 0 || *display.Contrast > 255 {
+			http.Error(w, "Contrast should be between 0 and 255", http.StatusBadRequest)
+			return
+		}
+		settings.Contrast = *display.Contrast
+	}
+	if display.Inverted != nil {
+		settings.Inverted = *display.Inverted
+	}
+	if display.On != nil {
+		settings.On = *display.On
+	}
+	if display.Duration != nil {
+		duration := *display.Duration
+		if duration > 3600 {
+			duration = 3600
+		} else if duration < 1 {
+			duration = 1
+		}
+		err = e.SetTemporaryDisplay(settings, time.Duration(duration)*time.Second)
+	} else {
+		err = e.SetDisplay(settings)
+	}
+	if err != nil {
+		engineFailed(w, e, "change display settings", err)
+	}
+}
+
+// engineFailed reports that the engine was unable to do what was asked,
+// with 503 when the screen is not connected, and 500 when it failed otherwise
+func engineFailed(w http.ResponseWriter, e engine.Engine, action string, err error) {
+	log.Printf("Unable to %s: %s", action, err)
+	status := http.StatusInternalServerError
+	if !e.Connected() {
+		status = http.StatusServiceUnavailable
+	}
+	http.Error(w, fmt.Sprintf("Unable to %s: %v", action, err), status)
+}
+
+// Orientation tells how the picture is turned on the panel
+type Orientation struct {
+	Rotation int  `json:"rotation"`
+	MirrorX  bool `json:"mirrorX"`
+	MirrorY  bool `json:"mirrorY"`
+	Lines    int  `json:"lines"`
+}
+
+// validRotation reports whether the screen can be turned by the given number of degrees
+func validRotation(rotation int) bool {
+	return rotation == 0 || rotation == 90 || rotation == 180 || rotation == 270
+}
+
+func handleGetOrientation(w http.ResponseWriter, r *http.Request) {
+	e, _ := engine.GetEngine()
+	o := e.GetOrientation()
+	json.NewEncoder(w).Encode(Orientation{o.Rotation, o.MirrorX, o.MirrorY, e.Lines()})
+}
+
+func handlePutOrientation(w http.ResponseWriter, r *http.Request) {
+	decoder := json.NewDecoder(r.Body)
+	var orientation Orientation
+	if err := decoder.Decode(&orientation); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	if !validRotation(orientation.Rotation) {
+		http.Error(w, "Rotation should be 0, 90, 180 or 270", http.StatusBadRequest)
+		return
+	}
+	e, _ := engine.GetEngine()
+	if err := e.SetOrientation(oled.Orientation{Rotation: orientation.Rotation, MirrorX: orientation.MirrorX, MirrorY: orientation.MirrorY}); err != nil {
+		engineFailed(w, e, "change orientation", err)
+	}
+}
+
+// maxSnapshotScale is the largest magnification of the screen snapshot
+const maxSnapshotScale = 16
+
+// magnified returns the image with every pixel turned into a square of the given size
+func magnified(img *image.Paletted, scale int) *image.Paletted {
+	if scale == 1 {
+		return img
+	}
+	rect := img.Bounds()
+	result := image.NewPaletted(image.Rect(0, 0, rect.Dx()*scale, rect.Dy()*scale), img.Palette)
+	for y := 0; y < result.Rect.Dy(); y++ {
+		for x := 0; x < result.Rect.Dx(); x++ {
+			result.SetColorIndex(x, y, img.ColorIndexAt(rect.Min.X+x/scale, rect.Min.Y+y/scale))
+		}
+	}
+	return result
+}
+
+func handleGetScreenImage(w http.ResponseWriter, r *http.Request) {
+	scale := 1
+	if value := r.URL.Query().Get("scale"); value != "" {
+		var err error
+		if scale, err = strconv.Atoi(value); err != nil || scale < 1 || scale > maxSnapshotScale {
+			http.Error(w, fmt.Sprintf("Scale should be between 1 and %d", maxSnapshotScale), http.StatusBadRequest)
+			return
+		}
+	}
+	e, _ := engine.GetEngine()
+	img, err := e.Snapshot()
+	if err != nil {
+		log.Printf("Unable to take snapshot: %s", err)
+		http.Error(w, "Screen not connected", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	png.Encode(w, magnified(img, scale))
+}
+
+func handleGetFonts(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(oled.FontNames())
+}
+
+func handleGetIcons(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(oled.IconNames())
+}
+
+// handlePutIcon registers the image in the body as an icon, converted to monochrome like other images
+func handlePutIcon(w http.ResponseWriter, r *http.Request) {
+	style, err := imageStyle(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	img, err := oled.DecodeImage(r.Body, imageFormat(r))
+	if err != nil {
+		log.Printf("Unable to decode icon: %s", err)
+		http.Error(w, "Unable to decode the provided image", http.StatusBadRequest)
+		return
+	}
+	b, err := oled.Dithered(img, style)
+	if err == nil {
+		err = oled.RegisterIcon(mux.Vars(r)["name"], b)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+}
+
+func handlePostIconDraw(w http.ResponseWriter, r *http.Request) {
+	var x, y int
+	var err error
+	if value := r.URL.Query().Get("x"); value != "" {
+		if x, err = strconv.Atoi(value); err != nil {
+			http.Error(w, "Invalid x", http.StatusBadRequest)
+			return
+		}
+	}
+	if value := r.URL.Query().Get("y"); value != "" {
+		if y, err = strconv.Atoi(value); err != nil {
+			http.Error(w, "Invalid y", http.StatusBadRequest)
+			return
+		}
+	}
+	name := mux.Vars(r)["name"]
+	if _, err := oled.LookupIcon(name); err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	e, _ := engine.GetEngine()
+	if err := e.DrawIcon(name, x, y); err != nil {
+		engineFailed(w, e, "draw icon", err)
+	}
+}
+
+// SignalLevel contains the position and the level of the signal indicator
+type SignalLevel struct {
+	Line   int `json:"line"`
+	Offset int `json:"offset"`
+	Level  int `json:"level"`
+}
+
+func handleGetSignalLevel(w http.ResponseWriter, r *http.Request) {
+	e, _ := engine.GetEngine()
+	indicator, found := e.GetSignalIndicator()
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+	json.NewEncoder(w).Encode(SignalLevel{indicator.Line, indicator.Offset, indicator.Level})
+}
+
+func handlePutSignalLevel(w http.ResponseWriter, r *http.Request) {
+	decoder := json.NewDecoder(r.Body)
+	var signal SignalLevel
+	if err := decoder.Decode(&signal); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	e, _ := engine.GetEngine()
+	indicator := engine.SignalIndicator{Line: signal.Line, Offset: signal.Offset, Level: signal.Level}
+	if err := e.CheckSignalIndicator(indicator); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := e.SetSignalIndicator(indicator); err != nil {
+		engineFailed(w, e, "show signal level", err)
+	}
+}
+
+func handleDeleteSignalLevel(w http.ResponseWriter, r *http.Request) {
+	e, _ := engine.GetEngine()
+	e.ClearSignalIndicator()
+}
+
 func handleDeleteMessages(w http.ResponseWriter, r *http.Request) {
 	e, _ := engine.GetEngine()
 	e.Clear()
@@ -110,13 +525,27 @@ func handlePutMessageOnLine(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	line := int(line64)
+	e, _ := engine.GetEngine()
+	if line >= e.Lines() {
+		http.NotFound(w, r)
+		return
+	}
 	decoder := json.NewDecoder(r.Body)
 	var msg Message
 	if err := decoder.Decode(&msg); err != nil {
 		http.Error(w, "Invalid body", http.StatusBadRequest)
 		return
 	}
-	e, _ := engine.GetEngine()
+	style, err := msg.style()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	marquee, err := msg.marquee()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if msg.Duration != nil {
 		duration := *msg.Duration
 		if duration > 3600 {
@@ -124,9 +553,12 @@ func handlePutMessageOnLine(w http.ResponseWriter, r *http.Request) {
 		} else if duration < 1 {
 			duration = 1
 		}
-		e.DisplayTemporaryMessage(msg.Text, line, time.Duration(duration)*time.Second)
+		e.DisplayTemporaryMessage(msg.Text, line, style, time.Duration(duration)*time.Second)
 	} else {
-		e.DisplayMessage(msg.Text, line)
+		e.DisplayMessage(msg.Text, line, style)
+	}
+	if marquee != nil {
+		e.ScrollMessage(line, *marquee)
 	}
 }
 
@@ -139,8 +571,11 @@ func handleGetMessageOnLine(w http.ResponseWriter, r *http.Request) {
 	}
 	line := int(line64)
 	e, _ := engine.GetEngine()
-	msg := MessageInfo{line, e.GetMessage(line)}
-	json.NewEncoder(w).Encode(msg)
+	if line >= e.Lines() {
+		http.NotFound(w, r)
+		return
+	}
+	json.NewEncoder(w).Encode(messageInfo(e, line))
 }
 
 func handleDeleteMessageOnLine(w http.ResponseWriter, r *http.Request) {
@@ -152,6 +587,10 @@ func handleDeleteMessageOnLine(w http.ResponseWriter, r *http.Request) {
 	}
 	line := int(line64)
 	e, _ := engine.GetEngine()
+	if line >= e.Lines() {
+		http.NotFound(w, r)
+		return
+	}
 	e.ClearMessage(line)
 }
 
@@ -199,19 +638,102 @@ func newRouter(loadPasswords func(auth.AuthenticationContext)) *mux.Router {
 	r.Use(middleware)
 	loadPasswords(context)
 	context.ExcludeOperation("POST", "/api/login")
+	context.ExcludeOperation("GET", "/simulator")
 	r.HandleFunc("/api/login", func(w http.ResponseWriter, r *http.Request) { handleLogin(context, w, r) }).Methods("POST")
 	r.HandleFunc("/api/health", handleGetHealth).Methods("GET")
 	r.HandleFunc("/api/messages", handleGetMessages).Methods("GET")
 	r.HandleFunc("/api/messages", handlePostMessage).Methods("POST")
 	r.HandleFunc("/api/messages", handleDeleteMessages).Methods("DELETE")
-	r.HandleFunc("/api/messages/{line:[0-7]}", handleGetMessageOnLine).Methods("GET")
-	r.HandleFunc("/api/messages/{line:[0-7]}", handlePutMessageOnLine).Methods("PUT")
-	r.HandleFunc("/api/messages/{line:[0-7]}", handleDeleteMessageOnLine).Methods("DELETE")
-	r.HandleFunc("/api/image/png", handlePostPngImage).Methods("POST")
+	r.HandleFunc("/api/messages/{line:[0-9]+}", handleGetMessageOnLine).Methods("GET")
+	r.HandleFunc("/api/messages/{line:[0-9]+}", handlePutMessageOnLine).Methods("PUT")
+	r.HandleFunc("/api/messages/{line:[0-9]+}", handleDeleteMessageOnLine).Methods("DELETE")
+	r.HandleFunc("/api/display", handleGetDisplay).Methods("GET")
+	r.HandleFunc("/api/display", handlePutDisplay).Methods("PUT")
+	r.HandleFunc("/api/orientation", handleGetOrientation).Methods("GET")
+	r.HandleFunc("/api/orientation", handlePutOrientation).Methods("PUT")
+	r.HandleFunc("/api/animation", handlePostAnimation).Methods("POST")
+	r.HandleFunc("/api/animation", handleDeleteAnimation).Methods("DELETE")
+	r.HandleFunc("/api/image", handlePostImage).Methods("POST")
+	r.HandleFunc("/api/image/{format:"+strings.Join(oled.ImageFormats(), "|")+"}", handlePostImage).Methods("POST")
+	r.HandleFunc("/api/screen.png", handleGetScreenImage).Methods("GET")
+	r.HandleFunc("/api/signal", handleGetSignalLevel).Methods("GET")
+	r.HandleFunc("/api/signal", handlePutSignalLevel).Methods("PUT")
+	r.HandleFunc("/api/signal", handleDeleteSignalLevel).Methods("DELETE")
+	r.HandleFunc("/api/fonts", handleGetFonts).Methods("GET")
+	r.HandleFunc("/api/icons", handleGetIcons).Methods("GET")
+	r.HandleFunc("/api/icons/{name:[a-z0-9_-]+}", handlePutIcon).Methods("PUT")
+	r.HandleFunc("/api/icons/{name:[a-z0-9_-]+}/draw", handlePostIconDraw).Methods("POST")
+	r.HandleFunc("/api/simulator", handleSimulatorSocket).Methods("GET")
+	r.HandleFunc("/simulator", handleGetSimulator).Methods("GET")
 	return r
 }
 
 func main() {
+	controllerName := flag.String("controller", "sh1106", "OLED controller chip: sh1106 or ssd1306")
+	bus := flag.String("bus", "", "I2C bus device, e.g. /dev/i2c-1 (probed when not set)")
+	address := flag.String("address", "", "I2C address of the screen, e.g. 0x3c (probed when not set)")
+	maxTransfer := flag.Int("max-transfer", 0, "largest number of bytes the I2C adapter writes at once, e.g. 32 (no limit when not set)")
+	spiDevice := flag.String("spi", "", "SPI device, e.g. /dev/spidev0.0 (I2C is used when not set)")
+	dcLine := flag.Int("dc", oled.DefaultDcLine, "GPIO line connected to DC of an SPI screen")
+	resetLine := flag.Int("reset", oled.DefaultResetLine, "GPIO line connected to RST of an SPI screen, -1 if not wired")
+	fontsDir := flag.String("fonts", "", "directory to load BDF and PCF fonts from")
+	fallbackFonts := flag.String("fallback-fonts", "", "comma separated fonts to look up characters missing in a font")
+	mock := flag.Bool("mock", false, "use a mock screen that only logs what is displayed")
+	virtual := flag.Bool("virtual", false, "use a screen that is only shown in the browser simulator")
+	simulate := flag.Bool("simulator", false, "show the screen in browsers at /simulator")
+	terminal := flag.Bool("terminal", false, "draw the screen in the terminal instead of using a real one")
+	braille := flag.Bool("braille", false, "draw the screen in the terminal with braille patterns, which takes less space")
+	rotate := flag.Int("rotate", 0, "clockwise rotation of the picture: 0, 90, 180 or 270")
+	mirrorX := flag.Bool("mirror-x", false, "mirror the picture horizontally")
+	mirrorY := flag.Bool("mirror-y", false, "mirror the picture vertically")
+	flag.Parse()
+	if !validRotation(*rotate) {
+		log.Fatalf("Error: invalid rotation %d", *rotate)
+	}
+	orientation := oled.Orientation{Rotation: *rotate, MirrorX: *mirrorX, MirrorY: *mirrorY}
+	if *fontsDir != "" {
+		fonts, err := oled.LoadFonts(*fontsDir)
+		if err != nil {
+			log.Fatalf("Error: %s", err)
+		}
+		log.Printf("Loaded %d fonts from %s", len(fonts), *fontsDir)
+	}
+	if *fallbackFonts != "" {
+		if err := oled.SetFallbackFonts(strings.Split(*fallbackFonts, ",")...); err != nil {
+			log.Fatalf("Error: %s", err)
+		}
+	}
+	controller, err := oled.ControllerByName(*controllerName)
+	if err != nil {
+		log.Fatalf("Error: %s", err)
+	}
+	if *simulate || *virtual {
+		screenSimulator = newSimulator()
+	}
+	var opener oled.Opener
+	if *virtual {
+		opener = &oled.VirtualOpener{Show: screenSimulator.show, Orientation: orientation}
+	} else if *mock {
+		opener = &oled.MockOpener{Orientation: orientation}
+	} else if *terminal {
+		opener = &oled.TerminalOpener{Braille: *braille, Orientation: orientation}
+	} else if *spiDevice != "" {
+		opener = &oled.SpiOpener{Controller: controller, Device: *spiDevice, DcLine: *dcLine, ResetLine: *resetLine, Orientation: orientation}
+	} else {
+		i2cOpener := &oled.I2cOpener{Controller: controller, Bus: *bus, Orientation: orientation, MaxTransfer: *maxTransfer}
+		if *address != "" {
+			addr, err := strconv.ParseUint(*address, 0, 7)
+			if err != nil || addr == 0 {
+				log.Fatalf("Error: invalid I2C address %s", *address)
+			}
+			i2cOpener.Address = int(addr)
+		}
+		opener = i2cOpener
+	}
+	if *simulate && !*virtual {
+		opener = &oled.MirrorOpener{Opener: opener, Show: screenSimulator.show}
+	}
+	engine.SetOpener(opener)
 	log.Printf("Initializing engine")
 	e, _ := engine.GetEngine()
 	defer e.Shutdown()
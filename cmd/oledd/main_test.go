@@ -3,19 +3,30 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strings"
 	"testing"
 
 	"github.com/gorilla/mux"
 	"github.com/samarkin/screen-server/auth"
+	"github.com/samarkin/screen-server/engine"
+	"github.com/samarkin/screen-server/oled"
 	"github.com/stretchr/testify/assert"
 )
 
 var r *mux.Router
 
+func TestMain(m *testing.M) {
+	engine.SetOpener(&oled.MockOpener{})
+	os.Exit(m.Run())
+}
+
 func TestAuthenticationRequired(t *testing.T) {
 	r = newRouter(createFakeUser)
 	response := executeRequest("GET", "/api/messages", "", nil)
@@ -56,6 +67,206 @@ func TestPutMessageChangesLine(t *testing.T) {
 	})
 }
 
+func TestPutScaledMessageCoversLines(t *testing.T) {
+	r = newRouter(createFakeUser)
+	token := login(t)
+	response := executeRequest("DELETE", "/api/messages", token, nil)
+	assertResponse(t, response, http.StatusOK, "")
+	jsonStr := []byte(`{"text": "12:30", "font": "digits", "scale": 2}`)
+	response = executeRequest("PUT", "/api/messages/2", token, bytes.NewBuffer(jsonStr))
+	assertResponse(t, response, http.StatusOK, "")
+
+	response = executeRequest("GET", "/api/messages", token, nil)
+
+	assertMessageInfo(t, response, func(lines []MessageInfo) {
+		assert.Equal(t, "12:30", lines[2].Text)
+		assert.Equal(t, 4, lines[2].Lines)
+		for i := 3; i < 6; i++ {
+			if assert.NotNil(t, lines[i].CoveredBy, "Line %d", i) {
+				assert.Equal(t, 2, *lines[i].CoveredBy, "Line %d", i)
+			}
+		}
+		assert.Nil(t, lines[6].CoveredBy)
+		assert.Equal(t, 1, lines[6].Lines)
+	})
+
+	response = executeRequest("DELETE", "/api/messages/4", token, nil)
+	assertResponse(t, response, http.StatusOK, "")
+	response = executeRequest("GET", "/api/messages/2", token, nil)
+	assert.Equal(t, http.StatusOK, response.Code)
+	var line MessageInfo
+	json.NewDecoder(response.Body).Decode(&line)
+	assert.Equal(t, "", line.Text)
+	assert.Equal(t, 1, line.Lines)
+}
+
+func TestPutMessageRejectsInvalidScale(t *testing.T) {
+	r = newRouter(createFakeUser)
+	token := login(t)
+	jsonStr := []byte(`{"text": "big", "scale": 8}`)
+	response := executeRequest("PUT", "/api/messages/0", token, bytes.NewBuffer(jsonStr))
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
+
+func TestPostImageRejectsInvalidStyle(t *testing.T) {
+	r = newRouter(createFakeUser)
+	token := login(t)
+	response := executeRequest("POST", "/api/image/png?dither=halftone", token, strings.NewReader(""))
+	assertResponse(t, response, http.StatusBadRequest, `Unsupported dithering "halftone"`)
+	response = executeRequest("POST", "/api/image/png?dither=atkinson&gamma=-1", token, strings.NewReader(""))
+	assertResponse(t, response, http.StatusBadRequest, "Invalid gamma")
+	response = executeRequest("POST", "/api/image/png?placement=tile", token, strings.NewReader(""))
+	assertResponse(t, response, http.StatusBadRequest, `Unsupported placement "tile"`)
+}
+
+func TestImageFormatFromRouteOrContentType(t *testing.T) {
+	r = newRouter(createFakeUser)
+	token := login(t)
+	response := executeRequest("POST", "/api/image/tiff", token, strings.NewReader(""))
+	assert.Equal(t, http.StatusNotFound, response.Code)
+
+	req := httptest.NewRequest("POST", "/api/image", nil)
+	req.Header.Set("Content-Type", "image/x-xbitmap; charset=us-ascii")
+	assert.Equal(t, "xbm", imageFormat(req))
+	req.Header.Set("Content-Type", "application/octet-stream")
+	assert.Equal(t, "", imageFormat(req))
+	req = mux.SetURLVars(req, map[string]string{"format": "pbm"})
+	assert.Equal(t, "pbm", imageFormat(req))
+}
+
+func TestPutDisplayChangesGivenSettings(t *testing.T) {
+	r = newRouter(createFakeUser)
+	token := login(t)
+	response := executeRequest("PUT", "/api/display", token, strings.NewReader(`{"contrast": 16, "inverted": true}`))
+	assertResponse(t, response, http.StatusOK, "")
+	response = executeRequest("PUT", "/api/display", token, strings.NewReader(`{"inverted": false}`))
+	assertResponse(t, response, http.StatusOK, "")
+
+	response = executeRequest("GET", "/api/display", token, nil)
+
+	if assert.Equal(t, http.StatusOK, response.Code) {
+		var display DisplayInfo
+		assert.NoError(t, json.NewDecoder(response.Body).Decode(&display))
+		assert.Equal(t, 16, display.Contrast)
+		assert.False(t, display.Inverted)
+		assert.True(t, display.On)
+	}
+
+	response = executeRequest("PUT", "/api/display", token, strings.NewReader(`{"contrast": 256}`))
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
+
+// connectionEngine is an engine that is connected or not, and does nothing else
+type connectionEngine struct {
+	engine.Engine
+	connected bool
+}
+
+func (e connectionEngine) Connected() bool {
+	return e.connected
+}
+
+func TestEngineFailureTellsWhetherScreenIsConnected(t *testing.T) {
+	response := httptest.NewRecorder()
+	engineFailed(response, connectionEngine{connected: false}, "change display settings", fmt.Errorf("screen not connected"))
+	assertResponse(t, response, http.StatusServiceUnavailable, "Unable to change display settings: screen not connected")
+	response = httptest.NewRecorder()
+	engineFailed(response, connectionEngine{connected: true}, "change display settings", fmt.Errorf("bus error"))
+	assertResponse(t, response, http.StatusInternalServerError, "Unable to change display settings: bus error")
+}
+
+func TestPutMessageRejectsInvalidMarquee(t *testing.T) {
+	r = newRouter(createFakeUser)
+	token := login(t)
+	response := executeRequest("PUT", "/api/messages/1", token, strings.NewReader(`{"text": "foobar", "marquee": "bounce"}`))
+	assertResponse(t, response, http.StatusBadRequest, "Marquee should be loop or once")
+	response = executeRequest("PUT", "/api/messages/1", token, strings.NewReader(`{"text": "foobar", "marquee": "loop", "speed": 1000}`))
+	assertResponse(t, response, http.StatusBadRequest, "Speed should be between 1 and 500")
+	response = executeRequest("POST", "/api/messages", token, strings.NewReader(`{"text": "foobar", "marquee": "loop"}`))
+	assertResponse(t, response, http.StatusBadRequest, "Marquee is not applicable here")
+}
+
+func TestGetScreenImageShowsMessages(t *testing.T) {
+	r = newRouter(createFakeUser)
+	token := login(t)
+	response := executeRequest("GET", "/api/screen.png?scale=0", token, nil)
+	assertResponse(t, response, http.StatusBadRequest, "Scale should be between 1 and 16")
+	response = executeRequest("DELETE", "/api/messages", token, nil)
+	assertResponse(t, response, http.StatusOK, "")
+	response = executeRequest("PUT", "/api/messages/7", token, strings.NewReader(`{"text": "_"}`))
+	assertResponse(t, response, http.StatusOK, "")
+
+	response = executeRequest("GET", "/api/screen.png?scale=2", token, nil)
+
+	if assert.Equal(t, http.StatusOK, response.Code) {
+		assert.Equal(t, "image/png", response.Header().Get("Content-Type"))
+		img, err := png.Decode(response.Body)
+		if assert.NoError(t, err) {
+			assert.Equal(t, image.Rect(0, 0, 256, 128), img.Bounds())
+			lit := 0
+			for y := 0; y < 128; y++ {
+				for x := 0; x < 256; x++ {
+					if red, _, _, _ := img.At(x, y).RGBA(); red != 0 {
+						lit++
+						assert.True(t, y >= 112, "Pixel (%d, %d) is lit", x, y)
+					}
+				}
+			}
+			assert.NotZero(t, lit)
+		}
+	}
+}
+
+func TestMagnifiedImage(t *testing.T) {
+	img := image.NewPaletted(image.Rect(0, 0, 2, 1), oled.Palette)
+	img.SetColorIndex(1, 0, 1)
+	result := magnified(img, 3)
+	assert.Equal(t, image.Rect(0, 0, 6, 3), result.Bounds())
+	assert.Equal(t, uint8(0), result.ColorIndexAt(2, 2))
+	assert.Equal(t, uint8(1), result.ColorIndexAt(3, 2))
+}
+
+func TestLinesBelowScreenAreNotFound(t *testing.T) {
+	r = newRouter(createFakeUser)
+	token := login(t)
+	response := executeRequest("PUT", "/api/messages/8", token, strings.NewReader(`{"text": "foobar"}`))
+	assert.Equal(t, http.StatusNotFound, response.Code)
+	response = executeRequest("GET", "/api/messages/8", token, nil)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+}
+
+func TestPutOrientationRejectsInvalidRotation(t *testing.T) {
+	r = newRouter(createFakeUser)
+	token := login(t)
+	response := executeRequest("PUT", "/api/orientation", token, strings.NewReader(`{"rotation": 45}`))
+	assertResponse(t, response, http.StatusBadRequest, "Rotation should be 0, 90, 180 or 270")
+
+	response = executeRequest("GET", "/api/orientation", token, nil)
+
+	if assert.Equal(t, http.StatusOK, response.Code) {
+		var orientation Orientation
+		assert.NoError(t, json.NewDecoder(response.Body).Decode(&orientation))
+		assert.Equal(t, 0, orientation.Rotation)
+		assert.Equal(t, 8, orientation.Lines)
+	}
+}
+
+func TestPutOrientationTurnsScreen(t *testing.T) {
+	r = newRouter(createFakeUser)
+	token := login(t)
+	response := executeRequest("PUT", "/api/orientation", token, strings.NewReader(`{"rotation": 180, "mirrorX": true}`))
+	assertResponse(t, response, http.StatusOK, "")
+	defer executeRequest("PUT", "/api/orientation", token, strings.NewReader(`{"rotation": 0}`))
+
+	response = executeRequest("GET", "/api/orientation", token, nil)
+
+	if assert.Equal(t, http.StatusOK, response.Code) {
+		var orientation Orientation
+		assert.NoError(t, json.NewDecoder(response.Body).Decode(&orientation))
+		assert.Equal(t, Orientation{Rotation: 180, MirrorX: true, Lines: 8}, orientation)
+	}
+}
+
 func TestDeleteMessagesClearsAll(t *testing.T) {
 	r = newRouter(createFakeUser)
 	token := login(t)
@@ -101,6 +312,97 @@ func TestDeleteMessageClearsLine(t *testing.T) {
 	})
 }
 
+func TestPutMessageRejectsUnknownFont(t *testing.T) {
+	r = newRouter(createFakeUser)
+	token := login(t)
+	jsonStr := []byte(`{"text": "foobar", "font": "no-such-font"}`)
+	response := executeRequest("PUT", "/api/messages/2", token, bytes.NewBuffer(jsonStr))
+	assertResponse(t, response, http.StatusBadRequest, `Unknown font "no-such-font"`)
+}
+
+func TestGetFontsListsBuiltinFont(t *testing.T) {
+	r = newRouter(createFakeUser)
+	token := login(t)
+	response := executeRequest("GET", "/api/fonts", token, nil)
+	if assert.Equal(t, http.StatusOK, response.Code) {
+		var fonts []string
+		assert.NoError(t, json.NewDecoder(response.Body).Decode(&fonts))
+		assert.Contains(t, fonts, "builtin")
+	}
+}
+
+func TestPutIconRegistersImageAndDrawsIt(t *testing.T) {
+	r = newRouter(createFakeUser)
+	token := login(t)
+	img := image.NewGray(image.Rect(0, 0, 3, 2))
+	for i := range img.Pix {
+		img.Pix[i] = 0xFF
+	}
+	img.Pix[0] = 0
+	var body bytes.Buffer
+	png.Encode(&body, img)
+	response := executeRequest("PUT", "/api/icons/dot", token, &body)
+	assertResponse(t, response, http.StatusOK, "")
+	response = executeRequest("PUT", "/api/icons/broken", token, strings.NewReader("not an image"))
+	assertResponse(t, response, http.StatusBadRequest, "Unable to decode the provided image")
+
+	response = executeRequest("GET", "/api/icons", token, nil)
+	if assert.Equal(t, http.StatusOK, response.Code) {
+		var icons []string
+		assert.NoError(t, json.NewDecoder(response.Body).Decode(&icons))
+		assert.Contains(t, icons, "dot")
+		assert.Contains(t, icons, "battery-full")
+		assert.NotContains(t, icons, "broken")
+	}
+
+	response = executeRequest("DELETE", "/api/messages", token, nil)
+	assertResponse(t, response, http.StatusOK, "")
+	response = executeRequest("POST", "/api/icons/dot/draw?x=5&y=x", token, nil)
+	assertResponse(t, response, http.StatusBadRequest, "Invalid y")
+	response = executeRequest("POST", "/api/icons/no-such-icon/draw", token, nil)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+	response = executeRequest("POST", "/api/icons/dot/draw?x=5&y=7", token, nil)
+	assertResponse(t, response, http.StatusOK, "")
+
+	response = executeRequest("GET", "/api/screen.png", token, nil)
+	if assert.Equal(t, http.StatusOK, response.Code) {
+		snapshot, err := png.Decode(response.Body)
+		if assert.NoError(t, err) {
+			for y := 0; y < 64; y++ {
+				for x := 0; x < 128; x++ {
+					red, _, _, _ := snapshot.At(x, y).RGBA()
+					assert.Equal(t, x == 5 && y == 7, red != 0, "Pixel (%d, %d)", x, y)
+				}
+			}
+		}
+	}
+}
+
+func TestSignalLevelCanBeSetAndCleared(t *testing.T) {
+	r = newRouter(createFakeUser)
+	token := login(t)
+	response := executeRequest("PUT", "/api/signal", token, strings.NewReader(`{"line": 1, "offset": 100, "level": 4}`))
+	assertResponse(t, response, http.StatusBadRequest, "Level should be between 0 and 3")
+	response = executeRequest("PUT", "/api/signal", token, strings.NewReader(`{"line": 1, "offset": 120, "level": 2}`))
+	assertResponse(t, response, http.StatusBadRequest, "Offset should be between 0 and 115")
+	response = executeRequest("PUT", "/api/signal", token, strings.NewReader(`{"line": 1, "offset": 100, "level": 2}`))
+	assertResponse(t, response, http.StatusOK, "")
+	response = executeRequest("PUT", "/api/messages/1", token, strings.NewReader(`{"text": "status"}`))
+	assertResponse(t, response, http.StatusOK, "")
+
+	response = executeRequest("GET", "/api/signal", token, nil)
+
+	if assert.Equal(t, http.StatusOK, response.Code) {
+		var signal SignalLevel
+		assert.NoError(t, json.NewDecoder(response.Body).Decode(&signal))
+		assert.Equal(t, SignalLevel{Line: 1, Offset: 100, Level: 2}, signal)
+	}
+	response = executeRequest("DELETE", "/api/signal", token, nil)
+	assertResponse(t, response, http.StatusOK, "")
+	response = executeRequest("GET", "/api/signal", token, nil)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+}
+
 func TestJsonRequiredWhenLoggingIn(t *testing.T) {
 	r = newRouter(createFakeUser)
 	nonJsonStr := []byte(`login=admin&password=admin`)
@@ -0,0 +1,130 @@
+package main
+
+import (
+	_ "embed"
+	"image"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/samarkin/screen-server/auth"
+)
+
+//go:embed simulator.html
+var simulatorPage []byte
+
+// screenSimulator shows the screen in browsers, it is nil unless oledd runs with -simulator or -virtual
+var screenSimulator *simulator
+
+// simulator passes the pictures of the screen to the browsers watching it
+type simulator struct {
+	mutex   sync.Mutex
+	frame   []byte
+	viewers map[chan []byte]bool
+}
+
+func newSimulator() *simulator {
+	return &simulator{viewers: make(map[chan []byte]bool)}
+}
+
+// encodeFrame packs the picture into a WebSocket message:
+// the width and the height, followed by the pixels row by row, 8 pixels per byte, most significant bit first
+func encodeFrame(img *image.Paletted) []byte {
+	rect := img.Bounds()
+	frame := make([]byte, 2+(rect.Dx()*rect.Dy()+7)/8)
+	frame[0], frame[1] = byte(rect.Dx()), byte(rect.Dy())
+	i := 0
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			if img.ColorIndexAt(x, y) != 0 {
+				frame[2+i/8] |= 0x80 >> uint(i%8)
+			}
+			i++
+		}
+	}
+	return frame
+}
+
+// show sends the picture to every viewer
+// A viewer that has not received the previous picture yet only gets the latest one
+func (s *simulator) show(img *image.Paletted) {
+	frame := encodeFrame(img)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.frame = frame
+	for viewer := range s.viewers {
+		select {
+		case <-viewer:
+		default:
+		}
+		viewer <- frame
+	}
+}
+
+// watch registers a viewer that gets the current picture right away
+func (s *simulator) watch() chan []byte {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	viewer := make(chan []byte, 1)
+	if s.frame != nil {
+		viewer <- s.frame
+	}
+	s.viewers[viewer] = true
+	return viewer
+}
+
+func (s *simulator) unwatch(viewer chan []byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.viewers, viewer)
+}
+
+func handleGetSimulator(w http.ResponseWriter, r *http.Request) {
+	if screenSimulator == nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(simulatorPage)
+}
+
+// handleSimulatorSocket streams the pictures of the screen over a WebSocket until the browser goes away
+// The session token comes as one of the subprotocols of the WebSocket, only that one is accepted back
+func handleSimulatorSocket(w http.ResponseWriter, r *http.Request) {
+	if screenSimulator == nil {
+		http.NotFound(w, r)
+		return
+	}
+	header := http.Header{}
+	if protocol := auth.WebSocketProtocol(r); protocol != "" {
+		header.Set("Sec-WebSocket-Protocol", protocol)
+	}
+	conn, err := (&websocket.Upgrader{}).Upgrade(w, r, header)
+	if err != nil {
+		log.Printf("Unable to open simulator socket: %s", err)
+		return
+	}
+	defer conn.Close()
+	viewer := screenSimulator.watch()
+	defer screenSimulator.unwatch(viewer)
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	for {
+		select {
+		case <-gone:
+			return
+		case frame := <-viewer:
+			if err := conn.WriteMessage(websocket.BinaryMessage, frame); err != nil {
+				return
+			}
+		}
+	}
+}
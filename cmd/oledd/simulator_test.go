@@ -0,0 +1,93 @@
+package main
+
+import (
+	"image"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/samarkin/screen-server/oled"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeFrame(t *testing.T) {
+	img := image.NewPaletted(image.Rect(0, 0, 4, 4), oled.Palette)
+	img.SetColorIndex(0, 0, 1)
+	img.SetColorIndex(1, 2, 1)
+	assert.Equal(t, []byte{4, 4, 0x80, 0x40}, encodeFrame(img))
+}
+
+func TestSimulatorPageIsPublic(t *testing.T) {
+	screenSimulator = nil
+	r = newRouter(createFakeUser)
+	response := executeRequest("GET", "/simulator", "", nil)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+
+	screenSimulator = newSimulator()
+	defer func() { screenSimulator = nil }()
+	response = executeRequest("GET", "/simulator", "", nil)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Body.String(), "<canvas")
+}
+
+func TestSimulatorStreamsFrames(t *testing.T) {
+	screenSimulator = newSimulator()
+	defer func() { screenSimulator = nil }()
+	r = newRouter(createFakeUser)
+	token := login(t)
+	server := httptest.NewServer(r)
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/simulator"
+
+	_, response, err := websocket.DefaultDialer.Dial(url, nil)
+	if assert.Error(t, err) && response != nil {
+		assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+	}
+
+	screenSimulator.show(image.NewPaletted(image.Rect(0, 0, 8, 1), oled.Palette))
+	dialer := websocket.Dialer{Subprotocols: []string{token}}
+	conn, _, err := dialer.Dial(url, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	_, frame, err := conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{8, 1, 0x00}, frame)
+
+	lit := image.NewPaletted(image.Rect(0, 0, 8, 1), oled.Palette)
+	lit.SetColorIndex(7, 0, 1)
+	screenSimulator.show(lit)
+	_, frame, err = conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{8, 1, 0x01}, frame)
+}
+
+func TestSimulatorAcceptsOnlyProtocolWithToken(t *testing.T) {
+	screenSimulator = newSimulator()
+	defer func() { screenSimulator = nil }()
+	r = newRouter(createFakeUser)
+	token := login(t)
+	server := httptest.NewServer(r)
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/simulator"
+
+	dialer := websocket.Dialer{Subprotocols: []string{"v1", token}}
+	conn, response, err := dialer.Dial(url, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	assert.Equal(t, token, response.Header.Get("Sec-WebSocket-Protocol"))
+	assert.Equal(t, token, conn.Subprotocol())
+
+	header := http.Header{"Sec-WebSocket-Protocol": []string{token + ", v1"}}
+	conn, response, err = websocket.DefaultDialer.Dial(url, header)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	assert.Equal(t, token, response.Header.Get("Sec-WebSocket-Protocol"))
+}
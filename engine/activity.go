@@ -0,0 +1,34 @@
+package engine
+
+// An activity keeps changing the screen in the background, e.g. plays an animation
+// Only one activity runs at a time, and displaying any other content stops it
+
+// startActivity runs the activity in a goroutine, stopping the one that is running
+// The activity gets a channel that is closed when it has to stop,
+// and must check it with the mutex locked every time before it draws
+// Must be called with the mutex locked
+func (e *engine) startActivity(run func(stop <-chan struct{})) {
+	e.stopActivity()
+	stop := make(chan struct{})
+	e.activity = stop
+	go run(stop)
+}
+
+// stopActivity stops the running activity, if any
+// Must be called with the mutex locked
+func (e *engine) stopActivity() {
+	if e.activity != nil {
+		close(e.activity)
+		e.activity = nil
+	}
+}
+
+// stopped reports whether the activity has been told to stop
+func stopped(stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
+	}
+}
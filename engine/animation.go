@@ -0,0 +1,119 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/samarkin/screen-server/oled"
+)
+
+func (e *engine) PlayAnimation(reader io.Reader, style oled.ImageStyle, loops int, duration time.Duration) error {
+	animation, err := oled.DecodeAnimation(reader, style.Format)
+	if err != nil {
+		return err
+	}
+	if loops == 0 {
+		loops = animation.LoopCount
+	}
+	e.mutex.Lock()
+	width, height := e.size()
+	e.mutex.Unlock()
+	frames := make([]*oled.Bitmap, len(animation.Frames))
+	for i, frame := range animation.Frames {
+		if frames[i], err = oled.RenderImage(frame, style, width, height); err != nil {
+			return err
+		}
+	}
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	log.Printf("Playing animation of %d frames...", len(frames))
+	if e.scr == nil {
+		return fmt.Errorf("screen not connected")
+	}
+	if e.fault != nil {
+		return fmt.Errorf("screen not connected: %v", e.fault)
+	}
+	e.stopMarquees()
+	for i := range e.messages {
+		e.messages[i] = message{animationPlaceholder, distantFuture, oled.TextStyle{}, i, 1}
+	}
+	e.startActivity(func(stop <-chan struct{}) {
+		e.animate(stop, frames, animation.Delays, loops, duration)
+	})
+	return nil
+}
+
+func (e *engine) StopAnimation() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.stopActivity()
+}
+
+// animate shows the frames in turn until the animation has been played the given number of times, 0 meaning forever,
+// or for the given duration, when not 0
+// The last frame stays on the screen when the animation is over, while the screen is cleared when the time is up
+func (e *engine) animate(stop <-chan struct{}, frames []*oled.Bitmap, delays []time.Duration, loops int, duration time.Duration) {
+	var timeUp <-chan time.Time
+	if duration > 0 {
+		timer := time.NewTimer(duration)
+		defer timer.Stop()
+		timeUp = timer.C
+	}
+	for loop := 0; loops == 0 || loop < loops; loop++ {
+		for i, frame := range frames {
+			if !e.showFrame(stop, frame) {
+				return
+			}
+			if len(frames) == 1 && timeUp == nil {
+				e.finishAnimation(stop, false)
+				return
+			}
+			select {
+			case <-stop:
+				return
+			case <-timeUp:
+				e.finishAnimation(stop, true)
+				return
+			case <-time.After(delays[i]):
+			}
+		}
+	}
+	e.finishAnimation(stop, false)
+}
+
+// showFrame draws the frame unless the animation has been stopped
+func (e *engine) showFrame(stop <-chan struct{}, frame *oled.Bitmap) bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if stopped(stop) || e.scr == nil {
+		return false
+	}
+	e.scr.Framebuffer().DrawBitmap(0, 0, frame)
+	if e.fault == nil {
+		if err := e.flush(nil); err != nil {
+			log.Printf("Unable to show animation frame: %s", err)
+		}
+	}
+	return true
+}
+
+// finishAnimation ends the animation that has not been stopped, erasing the screen if asked to
+func (e *engine) finishAnimation(stop <-chan struct{}, erase bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if stopped(stop) {
+		return
+	}
+	e.stopActivity()
+	if erase {
+		log.Printf("Erasing animation")
+		for i := range e.messages {
+			e.messages[i] = blank(i)
+		}
+		if e.scr != nil {
+			e.flush(e.scr.Clear())
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package engine
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/samarkin/screen-server/oled"
+)
+
+// newMockEngine returns an engine with a mock screen, bypassing the singleton
+func newMockEngine(t *testing.T) *engine {
+	e := &engine{mutex: &sync.Mutex{}}
+	scr, err := oled.Open(&oled.MockOpener{})
+	if err != nil {
+		t.Fatalf("Failed to open mock screen: %v", err)
+	}
+	e.scr = scr
+	e.messages = blankMessages(e.lines())
+	e.display = scr.DisplaySettings()
+	t.Cleanup(e.Shutdown)
+	return e
+}
+
+// blinkingGif returns an animation that alternates between a black and a white frame
+func blinkingGif(t *testing.T, delay int, loopCount int) *bytes.Buffer {
+	palette := color.Palette{color.Black, color.White}
+	g := &gif.GIF{LoopCount: loopCount}
+	for _, index := range []uint8{0, 1} {
+		frame := image.NewPaletted(image.Rect(0, 0, oled.Width, oled.Height), palette)
+		for i := range frame.Pix {
+			frame.Pix[i] = index
+		}
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, delay)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatalf("Failed to encode animation: %v", err)
+	}
+	return &buf
+}
+
+// lit reports whether the top left pixel of the screen is lit
+func (e *engine) lit() bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.scr.Framebuffer().Pixel(0, 0)
+}
+
+func (e *engine) running() bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.activity != nil
+}
+
+func TestAnimationPlaysLoops(t *testing.T) {
+	e := newMockEngine(t)
+	if err := e.PlayAnimation(blinkingGif(t, 2, 0), oled.ImageStyle{}, 2, 0); err != nil {
+		t.Fatalf("Failed to play animation: %v", err)
+	}
+	if e.GetMessage(0).Text != "<ANIMATION>" {
+		t.Errorf("Unexpected message %q", e.GetMessage(0).Text)
+	}
+	time.Sleep(150 * time.Millisecond)
+	if e.running() {
+		t.Fatalf("Animation is still running after 2 loops")
+	}
+	if e.lit() {
+		t.Errorf("The last, white, frame is not shown")
+	}
+}
+
+func TestAnimationIsInterrupted(t *testing.T) {
+	e := newMockEngine(t)
+	if err := e.PlayAnimation(blinkingGif(t, 2, 0), oled.ImageStyle{}, 0, 0); err != nil {
+		t.Fatalf("Failed to play animation: %v", err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if !e.running() {
+		t.Fatalf("Endless animation is not running")
+	}
+	e.DisplayMessage("Hello", 0, oled.TextStyle{})
+	if e.running() {
+		t.Fatalf("Animation is still running after a message is displayed")
+	}
+	shown := e.lit()
+	time.Sleep(50 * time.Millisecond)
+	if e.lit() != shown {
+		t.Errorf("Animation frames are still drawn")
+	}
+	if e.GetMessage(0).Text != "Hello" {
+		t.Errorf("Unexpected message %q", e.GetMessage(0).Text)
+	}
+}
+
+func TestAnimationDuration(t *testing.T) {
+	e := newMockEngine(t)
+	if err := e.PlayAnimation(blinkingGif(t, 2, 0), oled.ImageStyle{}, 0, 50*time.Millisecond); err != nil {
+		t.Fatalf("Failed to play animation: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if e.running() {
+		t.Fatalf("Animation is still running after its duration")
+	}
+	if e.lit() || e.GetMessage(0).Text != "" {
+		t.Errorf("Screen is not cleared after the animation")
+	}
+}
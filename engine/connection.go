@@ -0,0 +1,142 @@
+package engine
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/samarkin/screen-server/oled"
+)
+
+// The delay before the first attempt to reconnect to the screen, it doubles after every failed attempt up to the maximum
+var (
+	reconnectDelay    = time.Second
+	maxReconnectDelay = time.Minute
+)
+
+// ConnectionInfo tells how the connection to the screen is doing
+type ConnectionInfo struct {
+	// Connected tells whether the screen works
+	Connected bool
+	// Reconnecting tells whether the engine is trying to connect to the screen in the background
+	Reconnecting bool
+	// Attempts is the number of attempts to reconnect made since the engine started
+	Attempts int
+	// LastError is the last failure of the screen, it is kept after the screen is back, nil when it never failed
+	LastError error
+}
+
+func (e *engine) Connection() ConnectionInfo {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return ConnectionInfo{e.scr != nil && e.fault == nil, e.reconnecting != nil, e.attempts, e.lastError}
+}
+
+// disconnect notes the failure of the screen and starts reconnecting to it in the background
+// Drawing goes on in the framebuffer of the failed screen, and the picture is shown once the screen is back
+// Must be called with the mutex locked
+func (e *engine) disconnect(err error) {
+	log.Printf("Screen failed: %s", err)
+	e.fault = err
+	e.lastError = err
+	if e.reconnecting == nil {
+		stop := make(chan struct{})
+		e.reconnecting = stop
+		go e.reconnect(stop, reconnectDelay, maxReconnectDelay)
+	}
+}
+
+// reconnect opens the screen again and again, waiting twice as long after every failure up to maxDelay,
+// until it succeeds or is stopped
+func (e *engine) reconnect(stop <-chan struct{}, delay, maxDelay time.Duration) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(delay):
+		}
+		scr, err := oled.Open(e.opener)
+		if e.reconnected(stop, scr, err) {
+			return
+		}
+		if delay *= 2; delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+}
+
+// reconnected puts the screen that has been opened in place of the failed one, or notes why it could not be opened
+// It reports whether reconnecting is over
+func (e *engine) reconnected(stop <-chan struct{}, scr oled.Screen, err error) bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if stopped(stop) {
+		if scr != nil {
+			scr.Close()
+		}
+		return true
+	}
+	e.attempts++
+	if err == nil {
+		if err = e.restore(scr); err != nil {
+			scr.Close()
+		}
+	}
+	if err != nil {
+		log.Printf("Unable to reconnect to the screen: %s", err)
+		e.fault = err
+		e.lastError = err
+		return false
+	}
+	log.Printf("Reconnected to %s", scr.Description())
+	if e.scr != nil {
+		e.scr.Close()
+	}
+	e.scr = scr
+	e.fault = nil
+	e.reconnecting = nil
+	return true
+}
+
+// restore shows on the screen that has just been opened what the engine shows:
+// the picture of the failed screen, or the messages when the screen has never worked, under the signal indicator
+// Must be called with the mutex locked
+func (e *engine) restore(scr oled.Screen) error {
+	if e.scr != nil {
+		*scr.Framebuffer() = *e.scr.Framebuffer()
+		scr.Framebuffer().Invalidate()
+	} else if lines := scr.Framebuffer().Lines(); lines != len(e.messages) {
+		e.messages = blankMessages(lines)
+		e.cursorLine = 0
+	} else {
+		width, _ := scr.Framebuffer().Size()
+		for i, m := range e.messages {
+			if m.first != i || m.text == "" || m.text == imagePlaceholder || m.text == animationPlaceholder {
+				continue
+			}
+			shown, err := fitted(m.text, m.style, width)
+			if err != nil {
+				return err
+			}
+			if err := scr.PrintStyled(i, 0, shown, m.style); err != nil {
+				return err
+			}
+		}
+	}
+	if err := e.drawIndicator(scr); err != nil {
+		return err
+	}
+	// Settings nobody has set are those of the screen
+	if e.displayUnknown {
+		e.display = scr.DisplaySettings()
+		e.displayUnknown = false
+	}
+	if e.restoreUnknown {
+		*e.displayRestore = scr.DisplaySettings()
+		e.restoreUnknown = false
+	}
+	if err := sendDisplay(scr, e.display); err != nil {
+		return fmt.Errorf("Failed to restore display settings: %v", err)
+	}
+	return scr.Flush()
+}
@@ -0,0 +1,211 @@
+package engine
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/samarkin/screen-server/oled"
+)
+
+// faultyTransport records the transfers, or fails them all while it is broken
+type faultyTransport struct {
+	oled.RecordingTransport
+	mutex  sync.Mutex
+	broken bool
+}
+
+func (t *faultyTransport) setBroken(broken bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.broken = broken
+}
+
+func (t *faultyTransport) Command(cmds ...byte) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.broken {
+		return errors.New("remote I/O error")
+	}
+	return t.RecordingTransport.Command(cmds...)
+}
+
+func (t *faultyTransport) Data(payload []byte) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.broken {
+		return errors.New("remote I/O error")
+	}
+	return t.RecordingTransport.Data(payload)
+}
+
+// Close keeps the transport open, so that the screen can be opened on it again
+func (t *faultyTransport) Close() error {
+	return nil
+}
+
+// newFaultyEngine returns an engine with a screen on the transport, bypassing the singleton
+// The engine reconnects quickly until the test is over
+func newFaultyEngine(t *testing.T, tr *faultyTransport) *engine {
+	delay, maxDelay := reconnectDelay, maxReconnectDelay
+	reconnectDelay, maxReconnectDelay = 5*time.Millisecond, 20*time.Millisecond
+	e := newEngine(&oled.TransportOpener{Transport: tr})
+	t.Cleanup(func() {
+		e.Shutdown()
+		reconnectDelay, maxReconnectDelay = delay, maxDelay
+	})
+	return e
+}
+
+// waitForConnection waits for the engine to reconnect to the screen
+func waitForConnection(t *testing.T, e *engine) {
+	t.Helper()
+	for i := 0; i < 100 && !e.Connected(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !e.Connected() {
+		t.Fatalf("Screen is not reconnected")
+	}
+}
+
+// assertShows checks that the screen shows the given messages, one line each, and has nothing left to send
+func assertShows(t *testing.T, e *engine, lines map[int]string) {
+	t.Helper()
+	var expected oled.Framebuffer
+	for line, text := range lines {
+		expected.DrawText(0, line*8, text, oled.TextStyle{})
+	}
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	fb := e.scr.Framebuffer()
+	if fb.ASCII() != expected.ASCII() {
+		t.Errorf("Screen shows:\n%s", fb.ASCII())
+	}
+	for page := 0; page < oled.Pages; page++ {
+		if fb.Dirty(page) {
+			t.Errorf("Page %d is not sent to the screen", page)
+		}
+	}
+}
+
+func TestReconnectsAfterFailure(t *testing.T) {
+	tr := &faultyTransport{}
+	e := newFaultyEngine(t, tr)
+	e.DisplayMessage("before", 0, oled.TextStyle{})
+
+	tr.setBroken(true)
+	if err := e.DisplayMessage("during", 1, oled.TextStyle{}); err == nil {
+		t.Errorf("Failure of the screen is not reported")
+	}
+	if e.Connected() {
+		t.Errorf("Failed screen is reported as connected")
+	}
+	e.DisplayMessage("pending", 2, oled.TextStyle{})
+	time.Sleep(30 * time.Millisecond)
+	connection := e.Connection()
+	if !connection.Reconnecting || connection.Attempts == 0 || connection.LastError == nil {
+		t.Errorf("Unexpected connection state %+v", connection)
+	}
+
+	tr.setBroken(false)
+	waitForConnection(t, e)
+	assertShows(t, e, map[int]string{0: "before", 1: "during", 2: "pending"})
+	if e.Connection().Reconnecting {
+		t.Errorf("Engine keeps reconnecting")
+	}
+}
+
+func TestConnectsWhenScreenShowsUp(t *testing.T) {
+	tr := &faultyTransport{broken: true}
+	e := newFaultyEngine(t, tr)
+	if e.Connected() {
+		t.Fatalf("Broken screen is reported as connected")
+	}
+	e.DisplayMessage("waiting", 3, oled.TextStyle{})
+	e.SetDisplay(oled.DisplaySettings{Contrast: 7, On: true})
+
+	tr.setBroken(false)
+	waitForConnection(t, e)
+	assertShows(t, e, map[int]string{3: "waiting"})
+	if contrast := e.scr.DisplaySettings().Contrast; contrast != 7 {
+		t.Errorf("Contrast %d is not restored", contrast)
+	}
+}
+
+// defaultContrast returns the contrast a screen has once it is opened
+func defaultContrast(t *testing.T) int {
+	scr, err := oled.Open(&oled.TransportOpener{Transport: &oled.RecordingTransport{}})
+	if err != nil {
+		t.Fatalf("Failed to open screen: %v", err)
+	}
+	return scr.DisplaySettings().Contrast
+}
+
+// screenSettings returns the settings of the screen the engine draws on
+func screenSettings(e *engine) oled.DisplaySettings {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.scr.DisplaySettings()
+}
+
+func TestScreenShowingUpKeepsItsSettings(t *testing.T) {
+	tr := &faultyTransport{broken: true}
+	e := newFaultyEngine(t, tr)
+	e.DisplayMessage("waiting", 3, oled.TextStyle{})
+
+	tr.setBroken(false)
+	waitForConnection(t, e)
+	if contrast := screenSettings(e).Contrast; contrast != defaultContrast(t) {
+		t.Errorf("Contrast of the screen is changed to %d", contrast)
+	}
+	if e.GetDisplay() != screenSettings(e) {
+		t.Errorf("Engine reports settings %+v, the screen has %+v", e.GetDisplay(), screenSettings(e))
+	}
+}
+
+func TestTemporarySettingsExpireToThoseOfScreenShowingUp(t *testing.T) {
+	tr := &faultyTransport{broken: true}
+	e := newFaultyEngine(t, tr)
+	e.SetTemporaryDisplay(oled.DisplaySettings{Contrast: 7, On: true}, 50*time.Millisecond)
+
+	tr.setBroken(false)
+	waitForConnection(t, e)
+	time.Sleep(60 * time.Millisecond)
+	if contrast := screenSettings(e).Contrast; contrast != defaultContrast(t) {
+		t.Errorf("Contrast %d is restored instead of the one of the screen", contrast)
+	}
+}
+
+func TestOrientationIsKeptWhileReconnecting(t *testing.T) {
+	tr := &faultyTransport{}
+	e := newFaultyEngine(t, tr)
+	e.DisplayMessage("kept", 7, oled.TextStyle{})
+	tr.setBroken(true)
+	e.DisplayMessage("failed", 0, oled.TextStyle{})
+
+	if err := e.SetOrientation(oled.Orientation{Rotation: 90}); err == nil {
+		t.Errorf("Expected an error while reconnecting")
+	}
+	if o := e.GetOrientation(); o != (oled.Orientation{}) {
+		t.Errorf("Orientation is changed to %+v", o)
+	}
+	if e.Lines() != 8 || e.GetMessage(7).Text != "kept" {
+		t.Errorf("Messages are cleared")
+	}
+}
+
+func TestAnimationIsRefusedWhileReconnecting(t *testing.T) {
+	tr := &faultyTransport{}
+	e := newFaultyEngine(t, tr)
+	e.DisplayMessage("kept", 2, oled.TextStyle{})
+	tr.setBroken(true)
+	e.DisplayMessage("failed", 0, oled.TextStyle{})
+
+	if err := e.PlayAnimation(blinkingGif(t, 1, 0), oled.ImageStyle{}, 1, 0); err == nil {
+		t.Errorf("Expected an error while reconnecting")
+	}
+	if text := e.GetMessage(2).Text; text != "kept" {
+		t.Errorf("Message is replaced with %q", text)
+	}
+}
@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"time"
+
+	"github.com/samarkin/screen-server/oled"
+)
+
+func (e *engine) GetDisplay() oled.DisplaySettings {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.display
+}
+
+func (e *engine) SetDisplay(settings oled.DisplaySettings) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	log.Printf("Changing display settings to %+v...", settings)
+	e.displayRestore, e.restoreUnknown = nil, false
+	return e.applyDisplay(settings)
+}
+
+func (e *engine) SetTemporaryDisplay(settings oled.DisplaySettings, duration time.Duration) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	log.Printf("Changing display settings to %+v for %s...", settings, duration)
+	if e.displayRestore == nil {
+		restore := e.display
+		e.displayRestore = &restore
+		e.restoreUnknown = e.displayUnknown
+	}
+	e.displayExpiration = time.Now().Add(duration)
+	go func() {
+		time.Sleep(duration + smallDelay)
+		e.mutex.Lock()
+		defer e.mutex.Unlock()
+		if e.displayRestore != nil && time.Now().After(e.displayExpiration) {
+			log.Printf("Restoring display settings...")
+			restore, unknown := *e.displayRestore, e.restoreUnknown
+			e.displayRestore, e.restoreUnknown = nil, false
+			e.applyDisplay(restore)
+			e.displayUnknown = unknown
+		}
+	}()
+	return e.applyDisplay(settings)
+}
+
+// applyDisplay records the settings and sends them to the screen
+// A screen that is not connected gets them once it is back
+func (e *engine) applyDisplay(settings oled.DisplaySettings) error {
+	if settings.Contrast < 0 || settings.Contrast > 255 {
+		return fmt.Errorf("Contrast should be between 0 and 255")
+	}
+	e.display = settings
+	e.displayUnknown = false
+	if e.scr == nil {
+		return fmt.Errorf("screen not connected")
+	}
+	if e.fault != nil {
+		return fmt.Errorf("screen not connected: %v", e.fault)
+	}
+	if err := sendDisplay(e.scr, settings); err != nil {
+		e.disconnect(err)
+		return err
+	}
+	return nil
+}
+
+// sendDisplay sends the settings that differ from the current ones to the screen
+func sendDisplay(scr oled.Screen, settings oled.DisplaySettings) error {
+	current := scr.DisplaySettings()
+	if settings.Contrast != current.Contrast {
+		if err := scr.SetContrast(settings.Contrast); err != nil {
+			return err
+		}
+	}
+	if settings.Inverted != current.Inverted {
+		if err := scr.SetInverted(settings.Inverted); err != nil {
+			return err
+		}
+	}
+	if settings.On != current.On {
+		if err := scr.SetPower(settings.On); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Snapshot returns a picture of what the panel shows, taking inversion and power into account
+func (e *engine) Snapshot() (*image.Paletted, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.scr == nil {
+		return nil, fmt.Errorf("screen not connected")
+	}
+	return oled.PanelImage(e.scr.Framebuffer(), e.scr.DisplaySettings()), nil
+}
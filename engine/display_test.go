@@ -0,0 +1,111 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/samarkin/screen-server/oled"
+)
+
+func TestTemporaryDisplaySettings(t *testing.T) {
+	e := newMockEngine(t)
+	initial := e.GetDisplay()
+	flash := initial
+	flash.Inverted = true
+	if err := e.SetTemporaryDisplay(flash, 20*time.Millisecond); err != nil {
+		t.Fatalf("Failed to invert: %v", err)
+	}
+	if !e.scr.DisplaySettings().Inverted {
+		t.Errorf("Screen is not inverted")
+	}
+	dim := flash
+	dim.Contrast = 1
+	e.SetTemporaryDisplay(dim, 40*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+	if e.GetDisplay() != dim {
+		t.Errorf("Settings are restored before the last temporary change expires")
+	}
+	time.Sleep(40 * time.Millisecond)
+	if e.GetDisplay() != initial || e.scr.DisplaySettings() != initial {
+		t.Errorf("Settings %+v are not restored to %+v", e.scr.DisplaySettings(), initial)
+	}
+
+	e.SetTemporaryDisplay(flash, 10*time.Millisecond)
+	e.SetDisplay(oled.DisplaySettings{Contrast: 5, On: false})
+	time.Sleep(30 * time.Millisecond)
+	if e.scr.DisplaySettings() != (oled.DisplaySettings{Contrast: 5, On: false}) {
+		t.Errorf("Temporary settings override the permanent ones")
+	}
+}
+
+func TestPortraitOrientationHasSixteenLines(t *testing.T) {
+	e := newMockEngine(t)
+	e.DisplayMessage("landscape", 7, oled.TextStyle{})
+	if err := e.SetOrientation(oled.Orientation{Rotation: 90}); err != nil {
+		t.Fatalf("Failed to rotate: %v", err)
+	}
+	if e.Lines() != 16 {
+		t.Fatalf("Unexpected number of lines %d", e.Lines())
+	}
+	if m := e.GetMessage(7); m.Text != "" {
+		t.Errorf("Message %q is kept after switching to portrait", m.Text)
+	}
+	e.DisplayMessage("portrait", 15, oled.TextStyle{})
+	if m := e.GetMessage(15); m.Text != "portrait" {
+		t.Errorf("Unexpected message %q on the last line", m.Text)
+	}
+
+	e.SetOrientation(oled.Orientation{Rotation: 270})
+	if m := e.GetMessage(15); m.Text != "portrait" {
+		t.Errorf("Message is lost when turning the screen upside down")
+	}
+}
+
+func TestTemporaryMessageExpiresAfterRotation(t *testing.T) {
+	e := newMockEngine(t)
+	e.SetOrientation(oled.Orientation{Rotation: 90})
+	e.DisplayTemporaryMessage("portrait", 12, oled.TextStyle{}, 10*time.Millisecond)
+	if err := e.SetOrientation(oled.Orientation{}); err != nil {
+		t.Fatalf("Failed to rotate back: %v", err)
+	}
+	e.DisplayTemporaryMessage("landscape", 2, oled.TextStyle{}, 10*time.Millisecond)
+	time.Sleep(40 * time.Millisecond)
+	if e.Lines() != 8 {
+		t.Errorf("Unexpected number of lines %d", e.Lines())
+	}
+	if m := e.GetMessage(2); m.Text != "" {
+		t.Errorf("Message %q is not erased", m.Text)
+	}
+}
+
+func TestSnapshotShowsPanel(t *testing.T) {
+	e := newMockEngine(t)
+	e.scr.Framebuffer().SetPixel(2, 3, true)
+	img, err := e.Snapshot()
+	if err != nil {
+		t.Fatalf("Failed to take snapshot: %v", err)
+	}
+	if img.ColorIndexAt(2, 3) != 1 || img.ColorIndexAt(3, 3) != 0 {
+		t.Errorf("Snapshot does not match the framebuffer")
+	}
+	e.SetDisplay(oled.DisplaySettings{Contrast: 0x80, Inverted: true, On: true})
+	if img, _ = e.Snapshot(); img.ColorIndexAt(2, 3) != 0 || img.ColorIndexAt(3, 3) != 1 {
+		t.Errorf("Snapshot is not inverted")
+	}
+	e.SetDisplay(oled.DisplaySettings{Contrast: 0x80, On: false})
+	if img, _ = e.Snapshot(); img.ColorIndexAt(2, 3) != 0 {
+		t.Errorf("Snapshot of a panel turned off is not blank")
+	}
+}
+
+func TestMessagesAreRendered(t *testing.T) {
+	e := newMockEngine(t)
+	e.DisplayMessage("big", 2, oled.TextStyle{Scale: 2})
+	e.DisplayMessage("small", 6, oled.TextStyle{})
+	var expected oled.Framebuffer
+	expected.DrawText(0, 16, "big", oled.TextStyle{Scale: 2})
+	expected.DrawText(0, 48, "small", oled.TextStyle{})
+	if e.scr.Framebuffer().ASCII() != expected.ASCII() {
+		t.Errorf("Screen shows:\n%s", e.scr.Framebuffer().ASCII())
+	}
+}
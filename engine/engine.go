@@ -2,6 +2,7 @@ package engine
 
 import (
 	"fmt"
+	"image"
 	"io"
 	"log"
 	"strings"
@@ -14,147 +15,358 @@ import (
 // Engine is a singleton object to manage the screen
 type Engine interface {
 	Connected() bool
+	Connection() ConnectionInfo
+	Device() string
 	Clear() error
-	GetMessage(line int) string
-	DisplayMessage(text string, line int) error
-	DisplayTemporaryMessage(text string, line int, timeout time.Duration) error
-	DisplayImage(reader io.Reader) error
-	DisplayTemporaryImage(reader io.Reader, duration time.Duration) error
+	GetMessage(line int) MessageInfo
+	DisplayMessage(text string, line int, style oled.TextStyle) error
+	DisplayTemporaryMessage(text string, line int, style oled.TextStyle, timeout time.Duration) error
+	DisplayImage(reader io.Reader, style oled.ImageStyle) error
+	DisplayTemporaryImage(reader io.Reader, style oled.ImageStyle, duration time.Duration) error
+	DrawIcon(name string, x, y int) error
+	GetSignalIndicator() (SignalIndicator, bool)
+	CheckSignalIndicator(indicator SignalIndicator) error
+	SetSignalIndicator(indicator SignalIndicator) error
+	ClearSignalIndicator() error
 	ClearMessage(line int) error
-	AppendMessage(text string) error
+	AppendMessage(text string, style oled.TextStyle) error
+	ScrollMessage(line int, marquee Marquee) error
+	PlayAnimation(reader io.Reader, style oled.ImageStyle, loops int, duration time.Duration) error
+	StopAnimation()
+	GetOrientation() oled.Orientation
+	SetOrientation(o oled.Orientation) error
+	Lines() int
+	GetDisplay() oled.DisplaySettings
+	SetDisplay(settings oled.DisplaySettings) error
+	SetTemporaryDisplay(settings oled.DisplaySettings, duration time.Duration) error
+	Snapshot() (*image.Paletted, error)
 	Shutdown()
 }
 
 var instanceMutex = &sync.Mutex{}
-var instance Engine
-var initializationError error
+var instance *engine
+var opener oled.Opener = &oled.I2cOpener{}
 
-var padding = strings.Repeat(" ", 21)
+var padding = strings.Repeat(" ", 22)            // enough spaces of the built-in font to cover a line
 var distantFuture = time.Now().AddDate(10, 0, 0) // 10 years from now
 const smallDelay = 10 * time.Millisecond
 
+// SetOpener changes the way the screen is opened
+// It only has effect when called before the first call to GetEngine
+func SetOpener(o oled.Opener) {
+	instanceMutex.Lock()
+	defer instanceMutex.Unlock()
+	opener = o
+}
+
 // GetEngine instantiates a new, or returns an existing Engine instance
+// The error tells why the screen is not connected, the engine keeps trying to connect to it in the background
 // Use Engine.Connected() to see if screen has been connected successfully
 func GetEngine() (Engine, error) {
 	instanceMutex.Lock()
 	defer instanceMutex.Unlock()
 	if instance == nil {
-		e := &engine{}
-		e.mutex = &sync.Mutex{}
-		e.scr, initializationError = oled.Open(&oled.I2cOpener{})
-		instance = e
+		instance = newEngine(opener)
+	}
+	instance.mutex.Lock()
+	defer instance.mutex.Unlock()
+	return instance, instance.fault
+}
+
+// newEngine opens the screen with the given opener, and starts reconnecting in the background when it fails
+func newEngine(o oled.Opener) *engine {
+	e := &engine{mutex: &sync.Mutex{}, opener: o}
+	scr, err := oled.Open(o)
+	e.scr = scr
+	e.messages = blankMessages(e.lines())
+	e.display = oled.DisplaySettings{On: true}
+	if e.scr != nil {
+		e.display = e.scr.DisplaySettings()
+	} else {
+		e.displayUnknown = true
+		e.disconnect(err)
 	}
-	return instance, initializationError
+	return e
+}
+
+// MessageInfo describes the message that covers a line of the screen
+type MessageInfo struct {
+	// Text is the text of the message, empty when nothing is displayed
+	Text string
+	// Line is the first line of the message, it differs from the requested line when the line is covered by a message above
+	Line int
+	// Lines is the number of lines taken by the message
+	Lines int
 }
 
 type message struct {
 	text       string
 	expiration time.Time
+	style      oled.TextStyle
+	first      int // line where the message covering this line starts
+	lines      int // number of lines covered by the message starting on this line
+}
+
+// Texts recorded for the lines covered by an image or an animation
+const (
+	imagePlaceholder     = "<IMAGE>"
+	animationPlaceholder = "<ANIMATION>"
+)
+
+// blank returns the state of a line with nothing displayed on it
+func blank(line int) message {
+	return message{"", distantFuture, oled.TextStyle{}, line, 1}
+}
+
+// blankMessages returns the state of a screen with the given number of lines and nothing displayed on it
+func blankMessages(lines int) []message {
+	messages := make([]message, lines)
+	for i := range messages {
+		messages[i] = blank(i)
+	}
+	return messages
 }
 
 type engine struct {
 	mutex      *sync.Mutex
-	scr        oled.Screen
-	messages   [8]message
+	opener     oled.Opener
+	scr        oled.Screen // the screen that failed keeps being drawn on until it is back
+	messages   []message
 	cursorLine int
+	activity   chan struct{}
+	marquees   map[int]chan struct{} // stop channels of the scrolling messages by their first line
+	indicator  *SignalIndicator      // nil when no signal level is shown
+
+	display           oled.DisplaySettings
+	displayUnknown    bool                  // display is a placeholder until a screen tells its settings or they are set
+	displayRestore    *oled.DisplaySettings // settings to go back to when the temporary ones expire
+	restoreUnknown    bool                  // displayRestore is a placeholder for the settings of the first screen
+	displayExpiration time.Time
+
+	fault        error         // the reason the screen is not connected, nil when it works
+	lastError    error         // the last failure of the screen, kept after it has recovered
+	attempts     int           // number of attempts to reconnect to the screen
+	reconnecting chan struct{} // closed to stop reconnecting, nil when the screen works
 }
 
 func (e *engine) Connected() bool {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
-	return e.scr != nil
+	return e.scr != nil && e.fault == nil
+}
+
+func (e *engine) Device() string {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.scr == nil {
+		return ""
+	}
+	return e.scr.Description()
 }
 
 func (e *engine) Clear() error {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
+	e.stopActivity()
 	log.Printf("Clearing screen...")
+	e.stopMarquees()
 	for i := range e.messages {
-		e.messages[i] = message{"", distantFuture}
+		e.messages[i] = blank(i)
 	}
 	if e.scr == nil {
 		return fmt.Errorf("screen not connected")
 	}
-	return e.scr.Clear()
+	return e.flush(e.scr.Clear())
 }
 
 func (e *engine) ClearMessage(line int) error {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
+	e.stopActivity()
 	log.Printf("Clearing message on line %d...", line)
-	if line >= 0 && line < 8 {
-		e.messages[line] = message{"", distantFuture}
+	if line < 0 || line >= len(e.messages) {
+		if e.scr == nil {
+			return fmt.Errorf("screen not connected")
+		}
+		return e.flush(e.scr.Print(line, 0, padding))
 	}
+	err := e.erase(e.messages[line].first)
 	if e.scr == nil {
 		return fmt.Errorf("screen not connected")
 	}
-	return e.scr.Print(line, 0, padding)
+	return e.flush(err)
 }
 
-func (e *engine) AppendMessage(text string) error {
+func (e *engine) AppendMessage(text string, style oled.TextStyle) error {
+	lines, err := messageLines(style)
+	if err != nil {
+		return err
+	}
 	e.mutex.Lock()
 	cursorLine := e.cursorLine
-	e.cursorLine = (cursorLine + 1) & 0x07
+	if cursorLine+lines > len(e.messages) {
+		lines = len(e.messages) - cursorLine
+	}
+	e.cursorLine = (cursorLine + lines) % len(e.messages)
 	e.mutex.Unlock()
-	return e.DisplayMessage(text, cursorLine)
+	return e.DisplayMessage(text, cursorLine, style)
+}
+
+// messageLines returns the number of lines taken by a message in the given style
+func messageLines(style oled.TextStyle) (int, error) {
+	height, err := oled.TextHeight(style)
+	if err != nil {
+		return 0, err
+	}
+	if height <= 8 {
+		return 1, nil
+	}
+	return (height + 7) / 8, nil
+}
+
+// fitted truncates the text to the characters that fit on a line,
+// and appends enough spaces to blank the rest of the line
+func fitted(text string, style oled.TextStyle, lineWidth int) (string, error) {
+	shown, err := oled.FitText(text, style, lineWidth)
+	if err != nil {
+		return "", err
+	}
+	if shown != text {
+		log.Printf("Message \"%s\" is truncated to \"%s\"", text, shown)
+	}
+	width, _ := oled.MeasureText(shown, style)
+	space, _ := oled.MeasureText(" ", style)
+	if space < 1 {
+		space = 1
+	}
+	return shown + strings.Repeat(" ", (lineWidth-width)/space+1), nil
 }
 
-func (e *engine) DisplayMessage(text string, line int) error {
+func (e *engine) DisplayMessage(text string, line int, style oled.TextStyle) error {
+	lines, err := messageLines(style)
+	if err != nil {
+		return err
+	}
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
-	log.Printf("Displaying message \"%s\" on line %d...", text, line)
-	if line >= 0 && line < 8 {
-		e.messages[line] = message{text, distantFuture}
+	width, _ := e.size()
+	shown, err := fitted(text, style, width)
+	if err != nil {
+		return err
 	}
+	e.stopActivity()
+	log.Printf("Displaying message \"%s\" on line %d...", text, line)
+	err = e.place(message{text, distantFuture, style, line, lines}, shown)
 	if e.scr == nil {
 		return fmt.Errorf("screen not connected")
 	}
-	return e.scr.Print(line, 0, text+padding)
+	return e.flush(err)
 }
 
-func (e *engine) DisplayTemporaryMessage(text string, line int, duration time.Duration) error {
+func (e *engine) DisplayTemporaryMessage(text string, line int, style oled.TextStyle, duration time.Duration) error {
+	lines, err := messageLines(style)
+	if err != nil {
+		return err
+	}
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
+	width, _ := e.size()
+	shown, err := fitted(text, style, width)
+	if err != nil {
+		return err
+	}
+	e.stopActivity()
 	log.Printf("Displaying message \"%s\" on line %d for %s...", text, line, duration)
-	if line >= 0 && line < 8 {
-		e.messages[line] = message{text, time.Now().Add(duration)}
+	if line >= 0 && line < len(e.messages) {
 		go func() {
 			time.Sleep(duration + smallDelay)
 			e.mutex.Lock()
 			defer e.mutex.Unlock()
-			if time.Now().After(e.messages[line].expiration) {
+			// The lines may have changed with the orientation while the message was shown
+			if line < len(e.messages) && e.messages[line].first == line && time.Now().After(e.messages[line].expiration) {
 				log.Printf("Erasing message on line %d...", line)
-				e.messages[line] = message{"", distantFuture}
+				err := e.erase(line)
 				if e.scr != nil {
-					e.scr.Print(line, 0, padding)
+					e.flush(err)
 				}
 			}
 		}()
 	}
+	err = e.place(message{text, time.Now().Add(duration), style, line, lines}, shown)
 	if e.scr == nil {
 		return fmt.Errorf("screen not connected")
 	}
-	return e.scr.Print(line, 0, text+padding)
+	return e.flush(err)
+}
+
+// place records the message on its first line and the lines below it, and prints it when the screen is connected
+// Messages that share lines with it are erased entirely, so that no half of a tall message stays on the screen
+func (e *engine) place(m message, shown string) error {
+	line := m.first
+	if line < 0 || line >= len(e.messages) {
+		if e.scr == nil {
+			return nil
+		}
+		return e.scr.PrintStyled(line, 0, shown, m.style)
+	}
+	if line+m.lines > len(e.messages) {
+		m.lines = len(e.messages) - line
+	}
+	for i := line; i < line+m.lines; i++ {
+		if first := e.messages[i].first; first != line || e.messages[first].lines > m.lines {
+			if err := e.erase(first); err != nil {
+				return err
+			}
+		}
+	}
+	for i := line + 1; i < line+m.lines; i++ {
+		e.messages[i] = message{first: line}
+	}
+	e.stopMarquee(line)
+	e.messages[line] = m
+	if e.scr == nil {
+		return nil
+	}
+	return e.scr.PrintStyled(line, 0, shown, m.style)
+}
+
+// erase forgets the message starting on the given line and blanks its lines when the screen is connected
+func (e *engine) erase(line int) error {
+	e.stopMarquee(line)
+	lines := e.messages[line].lines
+	for i := line; i < line+lines; i++ {
+		e.messages[i] = blank(i)
+		if e.scr != nil {
+			if err := e.scr.Print(i, 0, padding); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
 
-func (e *engine) DisplayImage(reader io.Reader) error {
+func (e *engine) DisplayImage(reader io.Reader, style oled.ImageStyle) error {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
+	e.stopActivity()
+	e.stopMarquees()
 	for i := range e.messages {
-		e.messages[i] = message{"<IMAGE>", distantFuture}
+		e.messages[i] = message{imagePlaceholder, distantFuture, oled.TextStyle{}, i, 1}
 	}
 	if e.scr == nil {
 		return fmt.Errorf("screen not connected")
 	}
-	return e.scr.DisplayImage(reader)
+	return e.flush(e.scr.DisplayImageStyled(reader, style))
 }
 
-func (e *engine) DisplayTemporaryImage(reader io.Reader, duration time.Duration) error {
+func (e *engine) DisplayTemporaryImage(reader io.Reader, style oled.ImageStyle, duration time.Duration) error {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
+	e.stopActivity()
+	e.stopMarquees()
 	expiration := time.Now().Add(duration)
 	for i := range e.messages {
-		e.messages[i] = message{"<IMAGE>", expiration}
+		e.messages[i] = message{imagePlaceholder, expiration, oled.TextStyle{}, i, 1}
 	}
 	go func() {
 		time.Sleep(duration + smallDelay)
@@ -163,37 +375,86 @@ func (e *engine) DisplayTemporaryImage(reader io.Reader, duration time.Duration)
 		log.Printf("Erasing image")
 		now := time.Now()
 		for i := range e.messages {
-			if now.After(e.messages[i].expiration) {
-				e.messages[i] = message{"", distantFuture}
-				if e.scr != nil {
-					e.scr.Print(i, 0, padding)
-				}
+			if e.messages[i].first == i && now.After(e.messages[i].expiration) {
+				e.erase(i)
 			}
 		}
+		if e.scr != nil {
+			e.flush(nil)
+		}
 	}()
 	if e.scr == nil {
 		return fmt.Errorf("screen not connected")
 	}
-	return e.scr.DisplayImage(reader)
+	return e.flush(e.scr.DisplayImageStyled(reader, style))
+}
+
+// size returns the width and the height of the screen in its orientation, or those of a landscape screen when it is not connected
+func (e *engine) size() (int, int) {
+	if e.scr == nil {
+		return oled.Width, oled.Height
+	}
+	return e.scr.Framebuffer().Size()
+}
+
+// lines returns the number of lines of text that fit on the screen
+func (e *engine) lines() int {
+	if e.scr == nil {
+		return oled.Pages
+	}
+	return e.scr.Framebuffer().Lines()
+}
+
+func (e *engine) Lines() int {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return len(e.messages)
+}
+
+// flush draws the signal indicator over the pending changes and sends them to the screen unless drawing has failed
+// A screen that fails to take them is reconnected to, and they are sent once it is back
+func (e *engine) flush(err error) error {
+	if err != nil {
+		return err
+	}
+	if err := e.drawIndicator(e.scr); err != nil {
+		return err
+	}
+	if e.fault != nil {
+		return fmt.Errorf("screen not connected: %v", e.fault)
+	}
+	if err := e.scr.Flush(); err != nil {
+		e.disconnect(err)
+		return err
+	}
+	return nil
 }
 
-func (e *engine) GetMessage(line int) string {
+func (e *engine) GetMessage(line int) MessageInfo {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
-	if line >= 0 && line < 8 {
-		return e.messages[line].text
+	if line < 0 || line >= len(e.messages) {
+		return MessageInfo{"", line, 1}
 	}
-	return ""
+	m := e.messages[e.messages[line].first]
+	return MessageInfo{m.text, m.first, m.lines}
 }
 
 func (e *engine) Shutdown() {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
+	e.stopActivity()
 	log.Printf("Shutting down...")
+	e.stopMarquees()
+	if e.reconnecting != nil {
+		close(e.reconnecting)
+		e.reconnecting = nil
+	}
 	if e.scr != nil {
 		if err := e.scr.Clear(); err != nil {
 			e.scr.Print(0, 0, "Shutting down...")
 		}
+		e.scr.Flush()
 		e.scr.Close()
 		e.scr = nil
 	}
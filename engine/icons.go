@@ -0,0 +1,18 @@
+package engine
+
+import (
+	"fmt"
+	"log"
+)
+
+// DrawIcon draws the registered icon with its top left corner at (x, y) of the screen in its orientation
+// The icon stays until whatever is displayed at the same place replaces it
+func (e *engine) DrawIcon(name string, x, y int) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.scr == nil {
+		return fmt.Errorf("screen not connected")
+	}
+	log.Printf("Drawing icon %s at (%d, %d)", name, x, y)
+	return e.flush(e.scr.Framebuffer().DrawIcon(x, y, name))
+}
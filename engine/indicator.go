@@ -0,0 +1,111 @@
+package engine
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/samarkin/screen-server/oled"
+)
+
+// SignalIndicator is a signal level icon that stays on the screen over whatever is displayed there
+type SignalIndicator struct {
+	// Line is the line of the screen the icon is on
+	Line int
+	// Offset is the distance from the left edge of the screen to the icon, in pixels
+	Offset int
+	// Level is the signal level shown, 0 to oled.SignalLevels-1
+	Level int
+}
+
+// GetSignalIndicator returns the signal indicator and whether it is shown
+func (e *engine) GetSignalIndicator() (SignalIndicator, bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.indicator == nil {
+		return SignalIndicator{}, false
+	}
+	return *e.indicator, true
+}
+
+// CheckSignalIndicator tells whether the indicator has a valid level and fits on the screen
+func (e *engine) CheckSignalIndicator(indicator SignalIndicator) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.checkIndicator(indicator)
+}
+
+// checkIndicator tells whether the indicator has a valid level and fits on the screen
+// Must be called with the mutex locked
+func (e *engine) checkIndicator(indicator SignalIndicator) error {
+	if indicator.Level < 0 || indicator.Level >= oled.SignalLevels {
+		return fmt.Errorf("Level should be between 0 and %d", oled.SignalLevels-1)
+	}
+	if indicator.Line < 0 || indicator.Line >= len(e.messages) {
+		return fmt.Errorf("Line should be between 0 and %d", len(e.messages)-1)
+	}
+	if width, _ := e.size(); indicator.Offset < 0 || indicator.Offset > width-oled.SignalLevelWidth {
+		return fmt.Errorf("Offset should be between 0 and %d", width-oled.SignalLevelWidth)
+	}
+	return nil
+}
+
+// SetSignalIndicator shows the signal indicator, or moves it and changes its level when it is shown already
+// The indicator is drawn again after every change of the screen, so that messages on its line do not hide it
+func (e *engine) SetSignalIndicator(indicator SignalIndicator) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if err := e.checkIndicator(indicator); err != nil {
+		return err
+	}
+	log.Printf("Displaying signal level %d on line %d at offset %d...", indicator.Level, indicator.Line, indicator.Offset)
+	err := e.eraseIndicator()
+	e.indicator = &indicator
+	if e.scr == nil {
+		return fmt.Errorf("screen not connected")
+	}
+	return e.flush(err)
+}
+
+// ClearSignalIndicator removes the signal indicator and shows again what it covered
+func (e *engine) ClearSignalIndicator() error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.indicator == nil {
+		return nil
+	}
+	log.Printf("Clearing signal level...")
+	err := e.eraseIndicator()
+	e.indicator = nil
+	if e.scr == nil {
+		return fmt.Errorf("screen not connected")
+	}
+	return e.flush(err)
+}
+
+// drawIndicator draws the signal indicator on the screen when it is shown
+// Must be called with the mutex locked
+func (e *engine) drawIndicator(scr oled.Screen) error {
+	if e.indicator == nil {
+		return nil
+	}
+	return scr.DisplaySignalLevel(e.indicator.Line, e.indicator.Offset, e.indicator.Level)
+}
+
+// eraseIndicator blanks the signal indicator and prints again the message it covered, unless the message scrolls by itself
+// Must be called with the mutex locked
+func (e *engine) eraseIndicator() error {
+	if e.indicator == nil || e.scr == nil {
+		return nil
+	}
+	e.scr.Framebuffer().FillRect(e.indicator.Offset, e.indicator.Line*8, oled.SignalLevelWidth, 8, false)
+	m := e.messages[e.messages[e.indicator.Line].first]
+	if _, scrolls := e.marquees[m.first]; scrolls || m.text == "" || m.text == imagePlaceholder || m.text == animationPlaceholder {
+		return nil
+	}
+	width, _ := e.size()
+	shown, err := fitted(m.text, m.style, width)
+	if err != nil {
+		return err
+	}
+	return e.scr.PrintStyled(m.first, 0, shown, m.style)
+}
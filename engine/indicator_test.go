@@ -0,0 +1,75 @@
+package engine
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/samarkin/screen-server/oled"
+)
+
+// samePicture reports whether both engines show the same picture
+func samePicture(a, b *engine) bool {
+	return bytes.Equal(a.scr.Framebuffer().Image().Pix, b.scr.Framebuffer().Image().Pix)
+}
+
+func TestSignalIndicatorSurvivesMessages(t *testing.T) {
+	indicator := SignalIndicator{Line: 2, Offset: 100, Level: 3}
+	e := newMockEngine(t)
+	if err := e.SetSignalIndicator(indicator); err != nil {
+		t.Fatalf("Failed to show signal level: %v", err)
+	}
+	e.DisplayMessage("The quick brown fox jumps", 2, oled.TextStyle{})
+	expected := newMockEngine(t)
+	expected.DisplayMessage("The quick brown fox jumps", 2, oled.TextStyle{})
+	expected.scr.DisplaySignalLevel(2, 100, 3)
+	if !samePicture(e, expected) {
+		t.Errorf("Message hides the signal indicator")
+	}
+	if shown, found := e.GetSignalIndicator(); !found || shown != indicator {
+		t.Errorf("Unexpected signal indicator %+v", shown)
+	}
+
+	e.Clear()
+	expected.scr.Clear()
+	expected.scr.DisplaySignalLevel(2, 100, 3)
+	if !samePicture(e, expected) {
+		t.Errorf("Clearing the screen removes the signal indicator")
+	}
+
+	e.DisplayMessage("The quick brown fox jumps", 2, oled.TextStyle{})
+	if err := e.ClearSignalIndicator(); err != nil {
+		t.Fatalf("Failed to clear signal level: %v", err)
+	}
+	expected = newMockEngine(t)
+	expected.DisplayMessage("The quick brown fox jumps", 2, oled.TextStyle{})
+	if !samePicture(e, expected) {
+		t.Errorf("Message is not shown again where the signal indicator was")
+	}
+	if _, found := e.GetSignalIndicator(); found {
+		t.Errorf("Signal indicator is not cleared")
+	}
+}
+
+func TestSignalIndicatorMustFitOnScreen(t *testing.T) {
+	e := newMockEngine(t)
+	for _, indicator := range []SignalIndicator{
+		{Line: 0, Offset: 0, Level: oled.SignalLevels},
+		{Line: 0, Offset: 0, Level: -1},
+		{Line: 8, Offset: 0, Level: 0},
+		{Line: 0, Offset: oled.Width - oled.SignalLevelWidth + 1, Level: 0},
+		{Line: 0, Offset: -1, Level: 0},
+	} {
+		if err := e.CheckSignalIndicator(indicator); err == nil {
+			t.Errorf("Expected %+v not to pass the check", indicator)
+		}
+		if err := e.SetSignalIndicator(indicator); err == nil {
+			t.Errorf("Expected an error for %+v", indicator)
+		}
+	}
+	if err := e.CheckSignalIndicator(SignalIndicator{Line: 7, Offset: oled.Width - oled.SignalLevelWidth, Level: 0}); err != nil {
+		t.Errorf("Indicator in the bottom right corner does not pass the check: %v", err)
+	}
+	if _, found := e.GetSignalIndicator(); found {
+		t.Errorf("Invalid signal indicator is shown")
+	}
+}
@@ -0,0 +1,127 @@
+package engine
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/samarkin/screen-server/oled"
+)
+
+// DefaultMarqueeSpeed is the speed of a marquee that does not set one, in pixels per second
+const DefaultMarqueeSpeed = 30
+
+// marqueeGap is put between the end of a looping text and its beginning
+const marqueeGap = "   "
+
+// marqueePause is the time a marquee shows the beginning of the text before it starts scrolling
+var marqueePause = time.Second
+
+// Marquee tells how a message that does not fit on its line scrolls
+type Marquee struct {
+	// Speed is the number of pixels the text moves by every second, DefaultMarqueeSpeed when not set
+	Speed int
+	// Loop makes the text start over once it has scrolled by, otherwise it stops when its end shows up
+	Loop bool
+}
+
+// ScrollMessage makes the message starting on the given line scroll to the left,
+// until it is replaced or erased
+// Messages that fit on the line stay still
+func (e *engine) ScrollMessage(line int, marquee Marquee) error {
+	if marquee.Speed < 0 {
+		return fmt.Errorf("Speed should be positive")
+	}
+	if marquee.Speed == 0 {
+		marquee.Speed = DefaultMarqueeSpeed
+	}
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if line < 0 || line >= len(e.messages) || e.messages[line].first != line {
+		return fmt.Errorf("No message starts on line %d", line)
+	}
+	m := e.messages[line]
+	textWidth, err := oled.MeasureText(m.text, m.style)
+	if err != nil {
+		return err
+	}
+	width, _ := e.size()
+	if textWidth <= width {
+		return nil
+	}
+	log.Printf("Scrolling message on line %d at %d pixels per second...", line, marquee.Speed)
+	e.stopMarquee(line)
+	stop := make(chan struct{})
+	if e.marquees == nil {
+		e.marquees = make(map[int]chan struct{})
+	}
+	e.marquees[line] = stop
+	shown, last := m.text, textWidth-width
+	if marquee.Loop {
+		gap, _ := oled.MeasureText(marqueeGap, m.style)
+		shown, last = m.text+marqueeGap+m.text, textWidth+gap-1
+	}
+	go e.scroll(stop, line, shown, m.style, marquee, last)
+	return nil
+}
+
+// scroll moves the text by a pixel at a time until it is shifted by last pixels,
+// then starts over when the marquee loops
+func (e *engine) scroll(stop <-chan struct{}, line int, text string, style oled.TextStyle, marquee Marquee, last int) {
+	select {
+	case <-stop:
+		return
+	case <-time.After(marqueePause):
+	}
+	ticker := time.NewTicker(time.Second / time.Duration(marquee.Speed))
+	defer ticker.Stop()
+	for offset := 1; ; offset++ {
+		if offset > last {
+			if !marquee.Loop {
+				return
+			}
+			offset = 0
+		}
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		if !e.shift(stop, line, text, style, offset) {
+			return
+		}
+	}
+}
+
+// shift draws the text moved left by the given number of pixels unless the marquee has been stopped
+func (e *engine) shift(stop <-chan struct{}, line int, text string, style oled.TextStyle, offset int) bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if stopped(stop) || e.scr == nil {
+		return false
+	}
+	err := e.scr.PrintStyled(line, -offset, text, style)
+	if e.fault == nil {
+		if err = e.flush(err); err != nil {
+			log.Printf("Unable to scroll message: %s", err)
+		}
+	}
+	return true
+}
+
+// stopMarquee stops scrolling the message starting on the given line, if it scrolls
+// Must be called with the mutex locked
+func (e *engine) stopMarquee(line int) {
+	if stop, found := e.marquees[line]; found {
+		close(stop)
+		delete(e.marquees, line)
+	}
+}
+
+// stopMarquees stops scrolling all messages
+// Must be called with the mutex locked
+func (e *engine) stopMarquees() {
+	for line := range e.marquees {
+		e.stopMarquee(line)
+	}
+}
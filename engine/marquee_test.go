@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samarkin/screen-server/oled"
+)
+
+// lineShows reports whether the line of the screen looks like the text printed at the given offset
+func lineShows(e *engine, line int, text string, offset int) bool {
+	var expected oled.Framebuffer
+	expected.DrawText(offset, line*8, text, oled.TextStyle{})
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	for x := 0; x < oled.Width; x++ {
+		if e.scr.Framebuffer().Column(line, x) != expected.Column(line, x) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMarqueeStopsAtTheEnd(t *testing.T) {
+	marqueePause = 0
+	e := newMockEngine(t)
+	text := strings.Repeat("0123456789", 3)
+	e.DisplayMessage(text, 2, oled.TextStyle{})
+	if err := e.ScrollMessage(2, Marquee{Speed: 500}); err != nil {
+		t.Fatalf("Failed to scroll: %v", err)
+	}
+	width, _ := oled.MeasureText(text, oled.TextStyle{})
+	time.Sleep(200 * time.Millisecond)
+	if !lineShows(e, 2, text, oled.Width-width) {
+		t.Errorf("Marquee does not stop at the end of the text")
+	}
+}
+
+func TestMarqueeStopsWithMessage(t *testing.T) {
+	marqueePause = 0
+	e := newMockEngine(t)
+	e.DisplayMessage(strings.Repeat("scrolling ", 4), 0, oled.TextStyle{})
+	e.ScrollMessage(0, Marquee{Speed: 500, Loop: true})
+	time.Sleep(20 * time.Millisecond)
+	e.DisplayMessage("still", 0, oled.TextStyle{})
+	time.Sleep(20 * time.Millisecond)
+	if !lineShows(e, 0, "still"+padding, 0) {
+		t.Errorf("Marquee keeps scrolling after the message is replaced")
+	}
+	if len(e.marquees) != 0 {
+		t.Errorf("Marquee is not forgotten")
+	}
+
+	if err := e.ScrollMessage(oled.Pages, Marquee{}); err == nil {
+		t.Errorf("Line below the screen is scrolled")
+	}
+	if err := e.ScrollMessage(0, Marquee{}); err != nil || len(e.marquees) != 0 {
+		t.Errorf("Message that fits on the line is scrolled")
+	}
+}
@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/samarkin/screen-server/oled"
+)
+
+func (e *engine) GetOrientation() oled.Orientation {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.scr == nil {
+		return oled.Orientation{}
+	}
+	return e.scr.Framebuffer().Orientation()
+}
+
+// SetOrientation turns the picture on the screen
+// Messages and the signal indicator stay in place when the number of lines is the same,
+// and are cleared when the screen switches between landscape and portrait
+// Nothing changes while the screen is not connected
+func (e *engine) SetOrientation(o oled.Orientation) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.scr == nil {
+		return fmt.Errorf("screen not connected")
+	}
+	if e.fault != nil {
+		return fmt.Errorf("screen not connected: %v", e.fault)
+	}
+	log.Printf("Changing orientation to %+v...", o)
+	if err := e.scr.Framebuffer().SetOrientation(o); err != nil {
+		return err
+	}
+	if lines := e.lines(); lines != len(e.messages) {
+		e.stopActivity()
+		e.stopMarquees()
+		e.messages = blankMessages(lines)
+		e.cursorLine = 0
+		e.indicator = nil
+	}
+	return e.flush(nil)
+}
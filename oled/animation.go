@@ -0,0 +1,85 @@
+package oled
+
+import (
+	"bufio"
+	"bytes"
+	"image"
+	"image/draw"
+	"image/gif"
+	"io"
+	"time"
+)
+
+// DefaultFrameDelay is the delay of the animation frames that do not set one
+const DefaultFrameDelay = 100 * time.Millisecond
+
+// Animation is a sequence of frames shown one after another
+type Animation struct {
+	// Frames are the complete pictures to show, already composed from the changes GIF frames carry
+	Frames []image.Image
+	// Delays tell how long every frame stays on the screen
+	Delays []time.Duration
+	// LoopCount is the number of times the animation is played, 0 means forever
+	LoopCount int
+}
+
+// DecodeAnimation reads an animated GIF in the given format, an empty format is detected from the first bytes
+// Images in other formats make an animation of a single frame
+func DecodeAnimation(reader io.Reader, format string) (*Animation, error) {
+	r := bufio.NewReader(reader)
+	if format == "" {
+		if magic, _ := r.Peek(4); bytes.Equal(magic, []byte("GIF8")) {
+			format = "gif"
+		}
+	}
+	if format != "gif" {
+		img, err := DecodeImage(r, format)
+		if err != nil {
+			return nil, err
+		}
+		return &Animation{Frames: []image.Image{img}, Delays: []time.Duration{DefaultFrameDelay}, LoopCount: 1}, nil
+	}
+	g, err := gif.DecodeAll(r)
+	if err != nil {
+		return nil, err
+	}
+	a := &Animation{}
+	switch {
+	case g.LoopCount < 0:
+		a.LoopCount = 1
+	case g.LoopCount > 0:
+		a.LoopCount = g.LoopCount + 1
+	}
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if bounds.Empty() && len(g.Image) > 0 {
+		bounds = g.Image[0].Bounds()
+	}
+	canvas := image.NewRGBA(bounds)
+	for i, frame := range g.Image {
+		var previous *image.RGBA
+		disposal := byte(gif.DisposalNone)
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		if disposal == gif.DisposalPrevious {
+			previous = image.NewRGBA(bounds)
+			copy(previous.Pix, canvas.Pix)
+		}
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		composed := image.NewRGBA(bounds)
+		copy(composed.Pix, canvas.Pix)
+		a.Frames = append(a.Frames, composed)
+		delay := DefaultFrameDelay
+		if i < len(g.Delay) && g.Delay[i] > 1 {
+			delay = time.Duration(g.Delay[i]) * 10 * time.Millisecond
+		}
+		a.Delays = append(a.Delays, delay)
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+	return a, nil
+}
@@ -0,0 +1,67 @@
+package oled
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"testing"
+	"time"
+)
+
+func TestDecodeAnimationComposesFrames(t *testing.T) {
+	palette := color.Palette{color.Transparent, color.Black, color.White}
+	first := image.NewPaletted(image.Rect(0, 0, 4, 4), palette)
+	for i := range first.Pix {
+		first.Pix[i] = 2
+	}
+	second := image.NewPaletted(image.Rect(1, 1, 2, 2), palette)
+	second.Pix[0] = 1
+	third := image.NewPaletted(image.Rect(2, 2, 3, 3), palette)
+	third.Pix[0] = 1
+	g := &gif.GIF{
+		Image:     []*image.Paletted{first, second, third},
+		Delay:     []int{0, 5, 20},
+		Disposal:  []byte{gif.DisposalNone, gif.DisposalPrevious, gif.DisposalNone},
+		LoopCount: 2,
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+	a, err := DecodeAnimation(&buf, "")
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if len(a.Frames) != 3 || a.LoopCount != 3 {
+		t.Fatalf("Unexpected %d frames looped %d times", len(a.Frames), a.LoopCount)
+	}
+	expectedDelays := []time.Duration{DefaultFrameDelay, 50 * time.Millisecond, 200 * time.Millisecond}
+	for i, delay := range a.Delays {
+		if delay != expectedDelays[i] {
+			t.Errorf("Unexpected delay %s of frame %d", delay, i)
+		}
+	}
+	dark := func(frame, x, y int) bool {
+		return grayOnWhite(a.Frames[frame].At(x, y)) < 0x8000
+	}
+	if dark(0, 1, 1) || !dark(1, 1, 1) || dark(1, 0, 0) {
+		t.Errorf("Second frame is not drawn over the first one")
+	}
+	if dark(2, 1, 1) || !dark(2, 2, 2) {
+		t.Errorf("Second frame is not disposed of before the third one")
+	}
+}
+
+func TestDecodeAnimationOfStillImage(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewGray(image.Rect(0, 0, 8, 8)))
+	a, err := DecodeAnimation(&buf, "")
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if len(a.Frames) != 1 || a.LoopCount != 1 {
+		t.Errorf("Unexpected %d frames looped %d times", len(a.Frames), a.LoopCount)
+	}
+}
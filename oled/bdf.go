@@ -0,0 +1,143 @@
+package oled
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ParseBDF reads a font in the Glyph Bitmap Distribution Format
+// Glyph encodings are expected to be Unicode code points, which is the case for the ISO10646 and ISO8859-1 charsets
+func ParseBDF(reader io.Reader) (*Font, error) {
+	scanner := bufio.NewScanner(reader)
+	var name string
+	var ascent, descent int
+	var boxHeight, boxYOffset int
+	var registry, charset string
+	glyphs := make(map[rune]*Glyph)
+	var g *Glyph
+	var encoding int
+	var row int
+	inBitmap := false
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if inBitmap {
+			if fields[0] == "ENDCHAR" {
+				if encoding >= 0 {
+					glyphs[rune(encoding)] = g
+				}
+				g = nil
+				inBitmap = false
+				continue
+			}
+			if row >= g.Height {
+				return nil, fmt.Errorf("line %d: too many bitmap rows", lineNumber)
+			}
+			bytes, err := hex.DecodeString(fields[0])
+			if err != nil || len(bytes)*8 < g.Width {
+				return nil, fmt.Errorf("line %d: invalid bitmap row %q", lineNumber, fields[0])
+			}
+			for x := 0; x < g.Width; x++ {
+				g.bits[row*g.Width+x] = bytes[x/8]&(0x80>>uint(x%8)) != 0
+			}
+			row++
+			continue
+		}
+		values, err := bdfInts(fields[1:])
+		switch fields[0] {
+		case "FONT":
+			if len(fields) > 1 {
+				name = fields[1]
+			}
+		case "FONTBOUNDINGBOX":
+			if err != nil || len(values) != 4 {
+				return nil, fmt.Errorf("line %d: invalid bounding box", lineNumber)
+			}
+			boxHeight, boxYOffset = values[1], values[3]
+		case "FONT_ASCENT":
+			if err != nil || len(values) != 1 {
+				return nil, fmt.Errorf("line %d: invalid ascent", lineNumber)
+			}
+			ascent = values[0]
+		case "FONT_DESCENT":
+			if err != nil || len(values) != 1 {
+				return nil, fmt.Errorf("line %d: invalid descent", lineNumber)
+			}
+			descent = values[0]
+		case "CHARSET_REGISTRY":
+			registry = strings.ToUpper(strings.Trim(strings.Join(fields[1:], " "), "\""))
+		case "CHARSET_ENCODING":
+			charset = strings.Trim(strings.Join(fields[1:], " "), "\"")
+		case "STARTCHAR":
+			g = &Glyph{}
+			encoding = -1
+		case "ENCODING":
+			if g == nil || err != nil || len(values) < 1 {
+				return nil, fmt.Errorf("line %d: invalid encoding", lineNumber)
+			}
+			encoding = values[0]
+		case "DWIDTH":
+			if g == nil || err != nil || len(values) != 2 {
+				return nil, fmt.Errorf("line %d: invalid advance", lineNumber)
+			}
+			g.Advance = values[0]
+		case "BBX":
+			if g == nil || err != nil || len(values) != 4 || values[0] < 0 || values[1] < 0 {
+				return nil, fmt.Errorf("line %d: invalid glyph bounding box", lineNumber)
+			}
+			g.Width, g.Height, g.XOffset, g.YOffset = values[0], values[1], values[2], values[3]
+		case "BITMAP":
+			if g == nil {
+				return nil, fmt.Errorf("line %d: bitmap outside of a glyph", lineNumber)
+			}
+			g.bits = make([]bool, g.Width*g.Height)
+			row = 0
+			inBitmap = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := checkCharset(registry, charset); err != nil {
+		return nil, err
+	}
+	if len(glyphs) == 0 {
+		return nil, fmt.Errorf("no glyphs found")
+	}
+	if ascent == 0 && descent == 0 {
+		ascent, descent = boxHeight+boxYOffset, -boxYOffset
+	}
+	return newFont(name, ascent, descent, glyphs), nil
+}
+
+func bdfInts(fields []string) ([]int, error) {
+	values := make([]int, len(fields))
+	for i, field := range fields {
+		value, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// checkCharset makes sure that glyph encodings are Unicode code points
+// Fonts that do not specify the charset are assumed to be Unicode
+func checkCharset(registry, charset string) error {
+	switch {
+	case registry == "" || registry == "ISO10646":
+		return nil
+	case registry == "ISO8859" && charset == "1":
+		return nil
+	}
+	return fmt.Errorf("unsupported charset %s-%s", registry, charset)
+}
@@ -0,0 +1,114 @@
+package oled
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Controller describes the command set and RAM geometry of an OLED controller chip
+type Controller interface {
+	// Name returns the name of the controller chip
+	Name() string
+	// initSequence returns the commands that configure the controller, the display is left turned off
+	initSequence() []byte
+	// ramWidth returns the number of columns in the controller RAM
+	ramWidth() int
+	// columnOffset returns the RAM column that corresponds to the leftmost visible column
+	columnOffset() int
+	// setAddress returns the commands that move the write pointer to the given page and RAM column
+	setAddress(page, column int) []byte
+	// defaultContrast returns the contrast the controller has after initialization
+	defaultContrast() int
+}
+
+var (
+	// SH1106 drives most of the 1.3" modules, it has 132 columns of RAM centered around the visible 128
+	SH1106 Controller = sh1106{}
+	// SSD1306 drives most of the 0.96" modules, it has exactly 128 columns of RAM
+	SSD1306 Controller = ssd1306{}
+)
+
+// ControllerByName returns a supported controller given its case insensitive name
+func ControllerByName(name string) (Controller, error) {
+	for _, c := range []Controller{SH1106, SSD1306} {
+		if strings.EqualFold(c.Name(), name) {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("Unsupported controller %q", name)
+}
+
+type sh1106 struct{}
+
+func (sh1106) Name() string {
+	return "SH1106"
+}
+
+func (sh1106) initSequence() []byte {
+	return []byte{
+		0xAE, // display off
+		0xA1, // segment remap: rotate
+		0xC8, // COM scan direction: flip
+		0x40, // display start line 0
+	}
+}
+
+func (sh1106) defaultContrast() int {
+	return 0x80
+}
+
+func (sh1106) ramWidth() int {
+	return 132
+}
+
+func (sh1106) columnOffset() int {
+	return 2
+}
+
+// SH1106 only supports page addressing, so the write pointer wraps around within a page
+func (sh1106) setAddress(page, column int) []byte {
+	return []byte{0xB0 | byte(page&0x07), byte(column & 0x0F), 0x10 | byte((column>>4)&0x0F)}
+}
+
+type ssd1306 struct{}
+
+func (ssd1306) Name() string {
+	return "SSD1306"
+}
+
+func (ssd1306) initSequence() []byte {
+	return []byte{
+		0xAE,       // display off
+		0xD5, 0x80, // clock divide ratio and oscillator frequency
+		0xA8, 0x3F, // multiplex ratio: 64 lines
+		0xD3, 0x00, // display offset 0
+		0x40,       // display start line 0
+		0x8D, 0x14, // enable charge pump
+		0x20, 0x00, // horizontal addressing mode
+		0xA1,       // segment remap: rotate
+		0xC8,       // COM scan direction: flip
+		0xDA, 0x12, // alternative COM pins configuration
+		0x81, 0xCF, // contrast
+		0xD9, 0xF1, // pre-charge period
+		0xDB, 0x40, // VCOMH deselect level
+		0xA4, // display follows RAM contents
+		0xA6, // normal (not inverted) display
+	}
+}
+
+func (ssd1306) defaultContrast() int {
+	return 0xCF
+}
+
+func (ssd1306) ramWidth() int {
+	return 128
+}
+
+func (ssd1306) columnOffset() int {
+	return 0
+}
+
+// SSD1306 is used in horizontal addressing mode, so the write pointer moves on to the next page
+func (ssd1306) setAddress(page, column int) []byte {
+	return []byte{0x21, byte(column), 127, 0x22, byte(page & 0x07), 7}
+}
@@ -0,0 +1,25 @@
+package oled
+
+import "testing"
+
+func TestControllerByName(t *testing.T) {
+	for name, expected := range map[string]Controller{"sh1106": SH1106, "SSD1306": SSD1306} {
+		c, err := ControllerByName(name)
+		if err != nil {
+			t.Errorf("Failed to find %s: %v", name, err)
+		} else if c != expected {
+			t.Errorf("Unexpected controller %s for %s", c.Name(), name)
+		}
+	}
+	if _, err := ControllerByName("ST7565"); err == nil {
+		t.Errorf("Expected an error for an unsupported controller")
+	}
+}
+
+func TestControllerGeometry(t *testing.T) {
+	for _, c := range []Controller{SH1106, SSD1306} {
+		if c.columnOffset()*2+Width != c.ramWidth() {
+			t.Errorf("%s: visible columns are not centered in RAM", c.Name())
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package oled
+
+import (
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"sort"
+
+	"golang.org/x/image/bmp"
+)
+
+// imageDecoders decode the supported image formats given their names
+var imageDecoders = map[string]func(io.Reader) (image.Image, error){
+	"png":  png.Decode,
+	"gif":  gif.Decode,
+	"jpeg": jpeg.Decode,
+	"bmp":  bmp.Decode,
+	"pbm":  decodeNetpbm,
+	"pgm":  decodeNetpbm,
+	"xbm":  decodeXbm,
+}
+
+// ImageFormats returns the names of the supported image formats in alphabetical order
+func ImageFormats() []string {
+	names := make([]string, 0, len(imageDecoders))
+	for name := range imageDecoders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DecodeImage reads an image in the given format, an empty format is detected from the first bytes of the image
+func DecodeImage(reader io.Reader, format string) (image.Image, error) {
+	if format == "" {
+		img, _, err := image.Decode(reader)
+		return img, err
+	}
+	decode, found := imageDecoders[format]
+	if !found {
+		return nil, fmt.Errorf("Unsupported image format %q", format)
+	}
+	return decode(reader)
+}
@@ -0,0 +1,110 @@
+package oled
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/bmp"
+)
+
+// halves returns a 16x8 image with a black left half and a white right half
+func halves() *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, 16, 8))
+	for y := 0; y < 8; y++ {
+		for x := 8; x < 16; x++ {
+			img.SetGray(x, y, color.Gray{0xFF})
+		}
+	}
+	return img
+}
+
+func assertHalves(t *testing.T, img image.Image) {
+	t.Helper()
+	if size := img.Bounds().Size(); size != image.Pt(16, 8) {
+		t.Fatalf("Unexpected size %v", size)
+	}
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 16; x++ {
+			gray := color.GrayModel.Convert(img.At(img.Bounds().Min.X+x, img.Bounds().Min.Y+y)).(color.Gray).Y
+			if (gray < 0x80) != (x < 8) {
+				t.Fatalf("Unexpected gray 0x%02x at (%d, %d)", gray, x, y)
+			}
+		}
+	}
+}
+
+func TestDecodeImage(t *testing.T) {
+	encoders := map[string]func(io.Writer, image.Image) error{
+		"png":  png.Encode,
+		"gif":  func(w io.Writer, img image.Image) error { return gif.Encode(w, img, nil) },
+		"jpeg": func(w io.Writer, img image.Image) error { return jpeg.Encode(w, img, &jpeg.Options{Quality: 100}) },
+		"bmp":  bmp.Encode,
+	}
+	for format, encode := range encoders {
+		var buf bytes.Buffer
+		if err := encode(&buf, halves()); err != nil {
+			t.Fatalf("Failed to encode %s: %v", format, err)
+		}
+		data := buf.Bytes()
+		for _, given := range []string{"", format} {
+			img, err := DecodeImage(bytes.NewReader(data), given)
+			if err != nil {
+				t.Fatalf("Failed to decode %s given %q: %v", format, given, err)
+			}
+			assertHalves(t, img)
+		}
+	}
+}
+
+func TestDecodeNetpbm(t *testing.T) {
+	plainRow := "1 1 1 1 1 1 1 1 0 0 0 0 0 0 0 0\n"
+	plainGrayRow := "0 0 0 0 0 0 0 0 15 15 15 15 15 15 15 15\n"
+	images := map[string]string{
+		"plain PBM": "P1\n# halves\n16 8\n" + strings.Repeat(plainRow, 8),
+		"raw PBM":   "P4\n16 8\n" + strings.Repeat("\xFF\x00", 8),
+		"plain PGM": "P2 16 8 15\n" + strings.Repeat(plainGrayRow, 8),
+		"raw PGM":   "P5\n16 8\n255\n" + strings.Repeat(strings.Repeat("\x00", 8)+strings.Repeat("\xFF", 8), 8),
+	}
+	for name, data := range images {
+		img, err := DecodeImage(strings.NewReader(data), "")
+		if err != nil {
+			t.Fatalf("Failed to decode %s: %v", name, err)
+		}
+		assertHalves(t, img)
+	}
+	if _, err := DecodeImage(strings.NewReader("P4\n16 8\n\xFF"), "pbm"); err == nil {
+		t.Errorf("Expected an error for a truncated image")
+	}
+	for _, data := range []string{"P1\n0 0\n", "P1\n0 5\n", "P4\n8 0\n"} {
+		if _, err := DecodeImage(strings.NewReader(data), "pbm"); err == nil {
+			t.Errorf("Expected an error for an empty image %q", data)
+		}
+	}
+}
+
+func TestDecodeXbm(t *testing.T) {
+	data := `#define halves_width 16
+#define halves_height 8
+static unsigned char halves_bits[] = {
+   0xff, 0x00, 0xff, 0x00, 0xff, 0x00, 0xff, 0x00, 0xff, 0x00, 0xff, 0x00,
+   0xff, 0x00, 0xff, 0x00 };
+`
+	img, err := DecodeImage(strings.NewReader(data), "")
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	assertHalves(t, img)
+	if _, err := DecodeImage(strings.NewReader("/* icon */\n"+data), "xbm"); err != nil {
+		t.Errorf("Failed to decode XBM with a leading comment: %v", err)
+	}
+	if _, err := DecodeImage(strings.NewReader(data), "tiff"); err == nil {
+		t.Errorf("Expected an error for an unsupported format")
+	}
+}
@@ -0,0 +1,82 @@
+package oled
+
+// Segments of a seven-segment digit, a being the top one and g the middle one
+const (
+	segmentA = 1 << iota
+	segmentB
+	segmentC
+	segmentD
+	segmentE
+	segmentF
+	segmentG
+)
+
+const (
+	digitWidth     = 10
+	digitHeight    = 16
+	digitAdvance   = 12
+	digitThickness = 2
+)
+
+// digitSegments lists the lit segments of the characters of the digits font
+var digitSegments = map[rune]int{
+	'0': segmentA | segmentB | segmentC | segmentD | segmentE | segmentF,
+	'1': segmentB | segmentC,
+	'2': segmentA | segmentB | segmentG | segmentE | segmentD,
+	'3': segmentA | segmentB | segmentG | segmentC | segmentD,
+	'4': segmentF | segmentG | segmentB | segmentC,
+	'5': segmentA | segmentF | segmentG | segmentC | segmentD,
+	'6': segmentA | segmentF | segmentG | segmentE | segmentC | segmentD,
+	'7': segmentA | segmentB | segmentC,
+	'8': segmentA | segmentB | segmentC | segmentD | segmentE | segmentF | segmentG,
+	'9': segmentA | segmentB | segmentC | segmentD | segmentF | segmentG,
+	'-': segmentG,
+	' ': 0,
+}
+
+func init() {
+	glyphs := make(map[rune]*Glyph)
+	for ch, segments := range digitSegments {
+		glyphs[ch] = segmentsGlyph(segments)
+	}
+	glyphs[':'] = dotsGlyph(4, 11)
+	glyphs['.'] = dotsGlyph(14)
+	RegisterFont(newFont(BuiltinDigitsFont, digitHeight, 0, glyphs))
+}
+
+// segmentsGlyph draws the given segments of a digit
+func segmentsGlyph(segments int) *Glyph {
+	g := &Glyph{Width: digitWidth, Height: digitHeight, Advance: digitAdvance, bits: make([]bool, digitWidth*digitHeight)}
+	middle := (digitHeight - digitThickness) / 2
+	fill := func(segment, left, top, width, height int) {
+		if segments&segment == 0 {
+			return
+		}
+		for y := top; y < top+height; y++ {
+			for x := left; x < left+width; x++ {
+				g.bits[y*g.Width+x] = true
+			}
+		}
+	}
+	fill(segmentA, 0, 0, digitWidth, digitThickness)
+	fill(segmentG, 0, middle, digitWidth, digitThickness)
+	fill(segmentD, 0, digitHeight-digitThickness, digitWidth, digitThickness)
+	fill(segmentF, 0, 0, digitThickness, middle+digitThickness)
+	fill(segmentB, digitWidth-digitThickness, 0, digitThickness, middle+digitThickness)
+	fill(segmentE, 0, middle, digitThickness, digitHeight-middle)
+	fill(segmentC, digitWidth-digitThickness, middle, digitThickness, digitHeight-middle)
+	return g
+}
+
+// dotsGlyph draws square dots with their top edges on the given rows
+func dotsGlyph(rows ...int) *Glyph {
+	g := &Glyph{Width: digitThickness, Height: digitHeight, Advance: 2 * digitThickness, bits: make([]bool, digitThickness*digitHeight)}
+	for _, row := range rows {
+		for y := row; y < row+digitThickness; y++ {
+			for x := 0; x < digitThickness; x++ {
+				g.bits[y*g.Width+x] = true
+			}
+		}
+	}
+	return g
+}
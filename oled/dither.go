@@ -0,0 +1,180 @@
+package oled
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+)
+
+// Dither is an algorithm that turns shades of gray into lit and blank pixels
+type Dither int
+
+// Supported dithering algorithms
+const (
+	// DitherThreshold lights the pixels darker than the cutoff
+	DitherThreshold Dither = iota
+	// DitherFloydSteinberg diffuses the error of every pixel to its four neighbours
+	DitherFloydSteinberg
+	// DitherAtkinson diffuses three quarters of the error to six neighbours, which keeps more contrast
+	DitherAtkinson
+	// DitherBayer compares pixels to an 8x8 ordered threshold matrix, which gives a regular crosshatch
+	DitherBayer
+)
+
+var ditherNames = map[Dither]string{
+	DitherThreshold:      "threshold",
+	DitherFloydSteinberg: "floyd-steinberg",
+	DitherAtkinson:       "atkinson",
+	DitherBayer:          "bayer",
+}
+
+func (d Dither) String() string {
+	if name, found := ditherNames[d]; found {
+		return name
+	}
+	return fmt.Sprintf("Dither(%d)", int(d))
+}
+
+// DitherByName returns a dithering algorithm given its case insensitive name, e.g. floyd-steinberg
+func DitherByName(name string) (Dither, error) {
+	for d, n := range ditherNames {
+		if strings.EqualFold(n, name) {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("Unsupported dithering %q", name)
+}
+
+// ImageStyle tells how an image is placed on the screen and converted to monochrome
+// Dark parts of the image are lit on the screen
+type ImageStyle struct {
+	// Dither is the dithering algorithm, threshold when not set
+	Dither Dither
+	// Cutoff is the gray level, 1 to 255, below which pixels are lit by the threshold algorithm, 128 when not set
+	Cutoff int
+	// Gamma is applied to the gray levels before dithering, values above 1 brighten the midtones, 1 when not set
+	Gamma float64
+	// Placement tells how the image is scaled to the screen, fit when not set
+	Placement Placement
+	// X and Y shift the placed image right and down, the parts that leave the screen are cropped
+	X, Y int
+	// Format is the name of the image format, e.g. jpeg, see ImageFormats
+	// The format is detected from the first bytes of the image when not set
+	Format string
+}
+
+// check validates the style
+func (style ImageStyle) check() error {
+	if _, found := ditherNames[style.Dither]; !found {
+		return fmt.Errorf("Unsupported dithering %v", style.Dither)
+	}
+	if style.Cutoff < 0 || style.Cutoff > 255 {
+		return fmt.Errorf("Cutoff should be between 1 and 255")
+	}
+	if style.Gamma < 0 || math.IsNaN(style.Gamma) || math.IsInf(style.Gamma, 0) {
+		return fmt.Errorf("Gamma should be positive")
+	}
+	if _, found := placementNames[style.Placement]; !found {
+		return fmt.Errorf("Unsupported placement %v", style.Placement)
+	}
+	if _, found := imageDecoders[style.Format]; !found && style.Format != "" {
+		return fmt.Errorf("Unsupported image format %q", style.Format)
+	}
+	return nil
+}
+
+// bayer8 is the 8x8 ordered dithering matrix
+var bayer8 = [8][8]int{
+	{0, 32, 8, 40, 2, 34, 10, 42},
+	{48, 16, 56, 24, 50, 18, 58, 26},
+	{12, 44, 4, 36, 14, 46, 6, 38},
+	{60, 28, 52, 20, 62, 30, 54, 22},
+	{3, 35, 11, 43, 1, 33, 9, 41},
+	{51, 19, 59, 27, 49, 17, 57, 25},
+	{15, 47, 7, 39, 13, 45, 5, 37},
+	{63, 31, 55, 23, 61, 29, 53, 21},
+}
+
+// diffusion is a share of the error passed to the pixel at the given distance
+type diffusion struct {
+	dx, dy int
+	weight float64
+}
+
+var floydSteinberg = []diffusion{{1, 0, 7.0 / 16}, {-1, 1, 3.0 / 16}, {0, 1, 5.0 / 16}, {1, 1, 1.0 / 16}}
+var atkinson = []diffusion{{1, 0, 1.0 / 8}, {2, 0, 1.0 / 8}, {-1, 1, 1.0 / 8}, {0, 1, 1.0 / 8}, {1, 1, 1.0 / 8}, {0, 2, 1.0 / 8}}
+
+// Dithered converts the image to a bitmap of the same size in the given style
+func Dithered(img image.Image, style ImageStyle) (*Bitmap, error) {
+	if err := style.check(); err != nil {
+		return nil, err
+	}
+	rect := img.Bounds()
+	width, height := rect.Dx(), rect.Dy()
+	gamma := style.Gamma
+	if gamma == 0 {
+		gamma = 1
+	}
+	levels := make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			gray := grayOnWhite(img.At(rect.Min.X+x, rect.Min.Y+y))
+			levels[y*width+x] = math.Pow(float64(gray)/0xFFFF, 1/gamma)
+		}
+	}
+
+	b := NewBitmap(width, height)
+	switch style.Dither {
+	case DitherThreshold:
+		cutoff := style.Cutoff
+		if cutoff == 0 {
+			cutoff = 0x80
+		}
+		for i, level := range levels {
+			b.bits[i] = int(math.Round(level*255)) < cutoff
+		}
+	case DitherBayer:
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				b.bits[y*width+x] = levels[y*width+x] < (float64(bayer8[y%8][x%8])+0.5)/64
+			}
+		}
+	case DitherFloydSteinberg:
+		diffuse(b, levels, floydSteinberg)
+	case DitherAtkinson:
+		diffuse(b, levels, atkinson)
+	}
+	return b, nil
+}
+
+// grayOnWhite returns the 16-bit gray level of the color put on a white background,
+// so that transparent parts of images stay blank
+func grayOnWhite(c color.Color) uint32 {
+	r, g, b, a := c.RGBA()
+	// the same weights as color.GrayModel uses, the color components are premultiplied by alpha
+	y := (19595*r + 38470*g + 7471*b + 1<<15) >> 16
+	return y + 0xFFFF - a
+}
+
+// diffuse lights the dark pixels and spreads the difference between the gray level and the result to the neighbours
+func diffuse(b *Bitmap, levels []float64, shares []diffusion) {
+	for y := 0; y < b.Height; y++ {
+		for x := 0; x < b.Width; x++ {
+			level := levels[y*b.Width+x]
+			lit := level < 0.5
+			b.bits[y*b.Width+x] = lit
+			diff := level
+			if !lit {
+				diff = level - 1
+			}
+			for _, share := range shares {
+				nx, ny := x+share.dx, y+share.dy
+				if nx >= 0 && nx < b.Width && ny < b.Height {
+					levels[ny*b.Width+nx] += diff * share.weight
+				}
+			}
+		}
+	}
+}
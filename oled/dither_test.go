@@ -0,0 +1,80 @@
+package oled
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// uniform returns an image of the given gray level
+func uniform(level uint8) image.Image {
+	img := image.NewGray(image.Rect(0, 0, 16, 16))
+	for i := range img.Pix {
+		img.Pix[i] = level
+	}
+	return img
+}
+
+func countLit(b *Bitmap) int {
+	lit := 0
+	for y := 0; y < b.Height; y++ {
+		for x := 0; x < b.Width; x++ {
+			if b.Pixel(x, y) {
+				lit++
+			}
+		}
+	}
+	return lit
+}
+
+func TestDitherKeepsGrayLevel(t *testing.T) {
+	for _, dither := range []Dither{DitherFloydSteinberg, DitherAtkinson, DitherBayer} {
+		for _, level := range []uint8{0x40, 0x80, 0xC0} {
+			b, err := Dithered(uniform(level), ImageStyle{Dither: dither})
+			if err != nil {
+				t.Fatalf("Failed to dither: %v", err)
+			}
+			// dark pixels are lit, so the share of lit pixels is the darkness of the gray
+			expected := (255 - int(level)) * 256 / 255
+			if lit := countLit(b); lit < expected-24 || lit > expected+24 {
+				t.Errorf("%v lights %d of 256 pixels of gray 0x%02x, expected about %d", dither, lit, level, expected)
+			}
+		}
+	}
+}
+
+func TestThresholdCutoffAndGamma(t *testing.T) {
+	b, _ := Dithered(uniform(0x60), ImageStyle{})
+	if countLit(b) != 256 {
+		t.Errorf("Gray 0x60 is not lit with the default cutoff")
+	}
+	b, _ = Dithered(uniform(0x60), ImageStyle{Cutoff: 0x50})
+	if countLit(b) != 0 {
+		t.Errorf("Gray 0x60 is lit with cutoff 0x50")
+	}
+	b, _ = Dithered(uniform(0x60), ImageStyle{Gamma: 2.2})
+	if countLit(b) != 0 {
+		t.Errorf("Gray 0x60 is lit after gamma correction")
+	}
+	img := image.NewGray(image.Rect(0, 0, 2, 1))
+	img.SetGray(0, 0, color.Gray{0xFF})
+	b, _ = Dithered(img, ImageStyle{})
+	if b.Pixel(0, 0) || !b.Pixel(1, 0) {
+		t.Errorf("Unexpected threshold result")
+	}
+}
+
+func TestDitherByName(t *testing.T) {
+	for _, dither := range []Dither{DitherThreshold, DitherFloydSteinberg, DitherAtkinson, DitherBayer} {
+		found, err := DitherByName(dither.String())
+		if err != nil || found != dither {
+			t.Errorf("Failed to find %v by name: %v", dither, err)
+		}
+	}
+	if _, err := DitherByName("halftone"); err == nil {
+		t.Errorf("Expected an error for an unknown dithering")
+	}
+	if _, err := Dithered(uniform(0), ImageStyle{Gamma: -1}); err == nil {
+		t.Errorf("Expected an error for a negative gamma")
+	}
+}
@@ -0,0 +1,134 @@
+package oled
+
+// Bitmap is a monochrome picture of any size that can be drawn anywhere on the screen
+type Bitmap struct {
+	// Width and Height are the size of the bitmap
+	Width, Height int
+	bits          []bool
+}
+
+// NewBitmap creates a blank bitmap of the given size
+func NewBitmap(width, height int) *Bitmap {
+	if width < 0 {
+		width = 0
+	}
+	if height < 0 {
+		height = 0
+	}
+	return &Bitmap{Width: width, Height: height, bits: make([]bool, width*height)}
+}
+
+// Pixel reports whether the pixel of the bitmap is lit, (0, 0) being the top left corner
+func (b *Bitmap) Pixel(x, y int) bool {
+	if x < 0 || x >= b.Width || y < 0 || y >= b.Height {
+		return false
+	}
+	return b.bits[y*b.Width+x]
+}
+
+// SetPixel lights or blanks the pixel of the bitmap, pixels outside of the bitmap are ignored
+func (b *Bitmap) SetPixel(x, y int, on bool) {
+	if x < 0 || x >= b.Width || y < 0 || y >= b.Height {
+		return
+	}
+	b.bits[y*b.Width+x] = on
+}
+
+// DrawLine draws a straight line between two points, both ends included
+func (fb *Framebuffer) DrawLine(x0, y0, x1, y1 int, on bool) {
+	dx, dy := x1-x0, y1-y0
+	stepX, stepY := 1, 1
+	if dx < 0 {
+		dx, stepX = -dx, -1
+	}
+	if dy < 0 {
+		dy, stepY = -dy, -1
+	}
+	diff := dx - dy
+	for {
+		fb.SetPixel(x0, y0, on)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		double := 2 * diff
+		if double > -dy {
+			diff -= dy
+			x0 += stepX
+		}
+		if double < dx {
+			diff += dx
+			y0 += stepY
+		}
+	}
+}
+
+// DrawRect draws the outline of a rectangle with the top left corner at (x, y)
+func (fb *Framebuffer) DrawRect(x, y, width, height int, on bool) {
+	if width <= 0 || height <= 0 {
+		return
+	}
+	right, bottom := x+width-1, y+height-1
+	fb.DrawLine(x, y, right, y, on)
+	fb.DrawLine(x, bottom, right, bottom, on)
+	fb.DrawLine(x, y, x, bottom, on)
+	fb.DrawLine(right, y, right, bottom, on)
+}
+
+// FillRect lights or blanks every pixel of a rectangle with the top left corner at (x, y)
+func (fb *Framebuffer) FillRect(x, y, width, height int, on bool) {
+	for yy := y; yy < y+height; yy++ {
+		for xx := x; xx < x+width; xx++ {
+			fb.SetPixel(xx, yy, on)
+		}
+	}
+}
+
+// DrawCircle draws the outline of a circle with the center at (cx, cy)
+func (fb *Framebuffer) DrawCircle(cx, cy, radius int, on bool) {
+	fb.circle(cx, cy, radius, func(x0, x1, y int) {
+		fb.SetPixel(x0, y, on)
+		fb.SetPixel(x1, y, on)
+	})
+}
+
+// FillCircle lights or blanks every pixel of a circle with the center at (cx, cy)
+func (fb *Framebuffer) FillCircle(cx, cy, radius int, on bool) {
+	fb.circle(cx, cy, radius, func(x0, x1, y int) {
+		for x := x0; x <= x1; x++ {
+			fb.SetPixel(x, y, on)
+		}
+	})
+}
+
+// circle walks the outline of a circle with the midpoint algorithm,
+// passing the leftmost and the rightmost point of every row of the outline to span
+func (fb *Framebuffer) circle(cx, cy, radius int, span func(x0, x1, y int)) {
+	if radius < 0 {
+		return
+	}
+	x, y := radius, 0
+	diff := 1 - radius
+	for x >= y {
+		span(cx-x, cx+x, cy+y)
+		span(cx-x, cx+x, cy-y)
+		span(cx-y, cx+y, cy+x)
+		span(cx-y, cx+y, cy-x)
+		y++
+		if diff < 0 {
+			diff += 2*y + 1
+		} else {
+			x--
+			diff += 2*(y-x) + 1
+		}
+	}
+}
+
+// DrawBitmap copies the bitmap with its top left corner at (x, y)
+// Both lit and blank pixels of the bitmap are copied, the part outside of the screen is clipped
+func (fb *Framebuffer) DrawBitmap(x, y int, b *Bitmap) {
+	for yy := 0; yy < b.Height; yy++ {
+		for xx := 0; xx < b.Width; xx++ {
+			fb.SetPixel(x+xx, y+yy, b.bits[yy*b.Width+xx])
+		}
+	}
+}
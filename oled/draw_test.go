@@ -0,0 +1,126 @@
+package oled
+
+import "testing"
+
+// litPixels returns the lit pixels of the framebuffer in row order
+func litPixels(fb *Framebuffer) [][2]int {
+	var lit [][2]int
+	for y := 0; y < Height; y++ {
+		for x := 0; x < Width; x++ {
+			if fb.Pixel(x, y) {
+				lit = append(lit, [2]int{x, y})
+			}
+		}
+	}
+	return lit
+}
+
+func assertLit(t *testing.T, fb *Framebuffer, expected ...[2]int) {
+	t.Helper()
+	lit := litPixels(fb)
+	if len(lit) != len(expected) {
+		t.Fatalf("Expected pixels %v to be lit, got %v", expected, lit)
+	}
+	for i := range lit {
+		if lit[i] != expected[i] {
+			t.Fatalf("Expected pixels %v to be lit, got %v", expected, lit)
+		}
+	}
+}
+
+func TestDrawLine(t *testing.T) {
+	var fb Framebuffer
+	fb.DrawLine(3, 0, 0, 3, true)
+	assertLit(t, &fb, [2]int{3, 0}, [2]int{2, 1}, [2]int{1, 2}, [2]int{0, 3})
+
+	fb.Clear()
+	fb.DrawLine(0, 0, 4, 1, true)
+	if lit := litPixels(&fb); len(lit) != 5 || lit[0] != [2]int{0, 0} || lit[4] != [2]int{4, 1} {
+		t.Errorf("Unexpected shallow line %v", lit)
+	}
+
+	fb.Clear()
+	fb.DrawLine(5, 2, 5, 4, true)
+	assertLit(t, &fb, [2]int{5, 2}, [2]int{5, 3}, [2]int{5, 4})
+
+	fb.Clear()
+	fb.DrawLine(-10, -10, Height+10, Height+10, true)
+	if !fb.Pixel(0, 0) || !fb.Pixel(Height-1, Height-1) {
+		t.Errorf("Clipped diagonal line is not drawn")
+	}
+}
+
+func TestDrawRect(t *testing.T) {
+	var fb Framebuffer
+	fb.DrawRect(1, 1, 3, 3, true)
+	assertLit(t, &fb,
+		[2]int{1, 1}, [2]int{2, 1}, [2]int{3, 1},
+		[2]int{1, 2}, [2]int{3, 2},
+		[2]int{1, 3}, [2]int{2, 3}, [2]int{3, 3})
+
+	fb.FillRect(0, 0, 5, 5, true)
+	fb.FillRect(1, 1, 3, 3, false)
+	if len(litPixels(&fb)) != 16 {
+		t.Errorf("Unexpected filled rectangle %v", litPixels(&fb))
+	}
+}
+
+func TestDrawCircle(t *testing.T) {
+	var fb Framebuffer
+	fb.DrawCircle(10, 10, 1, true)
+	assertLit(t, &fb, [2]int{10, 9}, [2]int{9, 10}, [2]int{11, 10}, [2]int{10, 11})
+
+	fb.Clear()
+	fb.FillCircle(20, 20, 5, true)
+	for _, p := range [][2]int{{20, 20}, {15, 20}, {25, 20}, {20, 15}, {20, 25}} {
+		if !fb.Pixel(p[0], p[1]) {
+			t.Errorf("Pixel %v of the filled circle is not lit", p)
+		}
+	}
+	if fb.Pixel(15, 15) || fb.Pixel(25, 25) {
+		t.Errorf("Corners of the bounding box are lit")
+	}
+}
+
+func TestDrawBitmap(t *testing.T) {
+	b := NewBitmap(2, 2)
+	b.SetPixel(0, 0, true)
+	b.SetPixel(1, 1, true)
+	var fb Framebuffer
+	fb.FillRect(0, 0, Width, Height, true)
+	fb.DrawBitmap(Width-1, 10, b)
+	if !fb.Pixel(Width-1, 10) || fb.Pixel(Width-1, 11) {
+		t.Errorf("Bitmap is not copied")
+	}
+	if !fb.Pixel(0, 11) {
+		t.Errorf("Bitmap wraps around the edge of the screen")
+	}
+}
+
+func TestDrawOnMockScreen(t *testing.T) {
+	scr, err := Open(&MockOpener{})
+	if err != nil {
+		t.Fatalf("Failed to open mock screen: %v", err)
+	}
+	defer scr.Close()
+	scr.Framebuffer().DrawLine(0, 0, 0, 2, true)
+	assertLit(t, scr.Framebuffer(), [2]int{0, 0}, [2]int{0, 1}, [2]int{0, 2})
+	if err := scr.Flush(); err != nil {
+		t.Errorf("Failed to flush: %v", err)
+	}
+	scr.Clear()
+	assertLit(t, scr.Framebuffer())
+}
+
+func TestDrawShapes(t *testing.T) {
+	scr, tr := openRecording(t, SSD1306)
+	tr.Reset()
+	fb := scr.Framebuffer()
+	fb.DrawRect(0, 0, Width, Height, true)
+	fb.DrawLine(0, 0, Width-1, Height-1, true)
+	fb.DrawCircle(96, 20, 12, true)
+	fb.FillRect(8, 40, 20, 16, true)
+	fb.FillCircle(96, 48, 6, true)
+	scr.Flush()
+	assertGolden(t, "shapes", tr.String())
+}
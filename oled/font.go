@@ -0,0 +1,207 @@
+package oled
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// BuiltinFont is the name of the fixed width font that is compiled into the package
+	BuiltinFont = "builtin"
+	// BuiltinProportionalFont is the name of the built-in font with the blank columns around the glyphs trimmed
+	BuiltinProportionalFont = "builtin-proportional"
+	// BuiltinDigitsFont is the name of the built-in 16 pixels high font of seven-segment digits, for clocks and counters
+	BuiltinDigitsFont = "digits"
+)
+
+// Glyph is the bitmap of a single character
+// The bitmap is positioned relative to the origin of the character, which lies on the baseline
+type Glyph struct {
+	// Width and Height are the size of the bitmap
+	Width, Height int
+	// XOffset is the distance from the origin to the left edge of the bitmap
+	XOffset int
+	// YOffset is the distance from the baseline up to the bottom edge of the bitmap
+	YOffset int
+	// Advance is the distance from the origin to the origin of the next character
+	Advance int
+	bits    []bool
+}
+
+// Pixel reports whether the pixel of the bitmap is lit, (0, 0) being the top left corner
+func (g *Glyph) Pixel(x, y int) bool {
+	if x < 0 || x >= g.Width || y < 0 || y >= g.Height {
+		return false
+	}
+	return g.bits[y*g.Width+x]
+}
+
+// Font maps runes to glyphs
+type Font struct {
+	// Name is the name the font is registered with
+	Name string
+	// Ascent and Descent are the distances from the baseline to the top and the bottom of a line of text
+	Ascent, Descent int
+	// Width is the width of a character cell, the largest advance among the glyphs
+	Width  int
+	glyphs map[rune]*Glyph
+}
+
+// Height returns the height of a line of text
+func (f *Font) Height() int {
+	return f.Ascent + f.Descent
+}
+
+// Glyph returns the glyph of the given rune
+func (f *Font) Glyph(ch rune) (*Glyph, bool) {
+	g, found := f.glyphs[ch]
+	return g, found
+}
+
+// newFont creates a font out of a set of glyphs, Width is derived from the glyph advances
+func newFont(name string, ascent, descent int, glyphs map[rune]*Glyph) *Font {
+	f := &Font{Name: name, Ascent: ascent, Descent: descent, glyphs: glyphs}
+	for _, g := range glyphs {
+		if g.Advance > f.Width {
+			f.Width = g.Advance
+		}
+	}
+	return f
+}
+
+var fontsMutex = &sync.Mutex{}
+var fonts = map[string]*Font{}
+var fallbackFonts []string
+
+// RegisterFont makes the font available by its name, replacing a font with the same name
+func RegisterFont(f *Font) {
+	fontsMutex.Lock()
+	defer fontsMutex.Unlock()
+	fonts[f.Name] = f
+}
+
+// LookupFont returns a registered font given its name, an empty name means the built-in font
+func LookupFont(name string) (*Font, error) {
+	if name == "" {
+		name = BuiltinFont
+	}
+	fontsMutex.Lock()
+	defer fontsMutex.Unlock()
+	if f, found := fonts[name]; found {
+		return f, nil
+	}
+	return nil, fmt.Errorf("Unknown font %q", name)
+}
+
+// FontNames returns the names of all registered fonts in alphabetical order
+func FontNames() []string {
+	fontsMutex.Lock()
+	defer fontsMutex.Unlock()
+	names := make([]string, 0, len(fonts))
+	for name := range fonts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetFallbackFonts sets the fonts that are consulted, in order, for the runes a font has no glyph for
+// The built-in font always ends the chain, so runes missing everywhere are drawn as a box
+func SetFallbackFonts(names ...string) error {
+	for _, name := range names {
+		if _, err := LookupFont(name); err != nil {
+			return err
+		}
+	}
+	fontsMutex.Lock()
+	defer fontsMutex.Unlock()
+	fallbackFonts = append([]string{}, names...)
+	return nil
+}
+
+// findGlyph looks the rune up in the font and then in the fallback chain
+// The font that provided the glyph is returned along with it
+func findGlyph(f *Font, ch rune) (*Glyph, *Font) {
+	if g, found := f.Glyph(ch); found {
+		return g, f
+	}
+	fontsMutex.Lock()
+	builtin := fonts[BuiltinFont]
+	chain := make([]*Font, 0, len(fallbackFonts)+1)
+	for _, name := range fallbackFonts {
+		if fallback, found := fonts[name]; found && fallback != f {
+			chain = append(chain, fallback)
+		}
+	}
+	fontsMutex.Unlock()
+	if builtin != f {
+		chain = append(chain, builtin)
+	}
+	for _, fallback := range chain {
+		if g, found := fallback.Glyph(ch); found {
+			return g, fallback
+		}
+	}
+	return builtinUnknownGlyph, builtin
+}
+
+// LoadFont parses a BDF or PCF font file and registers it by the file name without extensions,
+// e.g. /usr/share/fonts/X11/misc/6x13.pcf.gz becomes 6x13
+func LoadFont(path string) (*Font, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	base := filepath.Base(path)
+	name := strings.TrimSuffix(base, ".gz")
+	var reader io.Reader = file
+	if name != base {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load %s: %v", base, err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+	var f *Font
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".bdf":
+		f, err = ParseBDF(reader)
+	case ".pcf":
+		f, err = ParsePCF(reader)
+	default:
+		return nil, fmt.Errorf("Unsupported font format %s", base)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load %s: %v", base, err)
+	}
+	f.Name = strings.TrimSuffix(name, filepath.Ext(name))
+	RegisterFont(f)
+	return f, nil
+}
+
+// LoadFonts loads and registers every BDF and PCF font in the given directory
+func LoadFonts(dir string) ([]*Font, error) {
+	var loaded []*Font
+	for _, pattern := range []string{"*.bdf", "*.pcf", "*.pcf.gz", "*.bdf.gz"} {
+		paths, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return loaded, err
+		}
+		for _, path := range paths {
+			f, err := LoadFont(path)
+			if err != nil {
+				return loaded, err
+			}
+			loaded = append(loaded, f)
+		}
+	}
+	return loaded, nil
+}
@@ -0,0 +1,238 @@
+package oled
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testBDF = `STARTFONT 2.1
+FONT -Test-Fixed-Medium-R-Normal--6-60-75-75-C-40-ISO10646-1
+SIZE 6 75 75
+FONTBOUNDINGBOX 4 6 0 -1
+STARTPROPERTIES 4
+FONT_ASCENT 5
+FONT_DESCENT 1
+CHARSET_REGISTRY "ISO10646"
+CHARSET_ENCODING "1"
+ENDPROPERTIES
+CHARS 2
+STARTCHAR A
+ENCODING 65
+SWIDTH 666 0
+DWIDTH 4 0
+BBX 3 5 0 0
+BITMAP
+40
+A0
+E0
+A0
+A0
+ENDCHAR
+STARTCHAR afii10024
+ENCODING 1046
+SWIDTH 666 0
+DWIDTH 4 0
+BBX 3 6 0 -1
+BITMAP
+A0
+A0
+40
+A0
+A0
+20
+ENDCHAR
+ENDFONT
+`
+
+// testGlyphRows are the rows of the glyphs in testBDF, from the top of the line
+var testGlyphRows = map[rune][]string{
+	'A': {".#.", "#.#", "###", "#.#", "#.#", "..."},
+	'Ж': {"#.#", "#.#", ".#.", "#.#", "#.#", "..#"},
+}
+
+func assertTestFont(t *testing.T, f *Font) {
+	t.Helper()
+	if f.Ascent != 5 || f.Descent != 1 || f.Width != 4 {
+		t.Errorf("Unexpected metrics: ascent %d, descent %d, width %d", f.Ascent, f.Descent, f.Width)
+	}
+	f.Name = "test-" + t.Name()
+	RegisterFont(f)
+	var fb Framebuffer
+	fb.DrawText(0, 0, "AЖ", TextStyle{Font: f.Name})
+	for i, ch := range []rune{'A', 'Ж'} {
+		for y, row := range testGlyphRows[ch] {
+			for x, pixel := range row {
+				if fb.Pixel(i*4+x, y) != (pixel == '#') {
+					t.Errorf("Unexpected pixel (%d, %d) of %q", x, y, ch)
+				}
+			}
+		}
+	}
+}
+
+func TestParseBDF(t *testing.T) {
+	f, err := ParseBDF(strings.NewReader(testBDF))
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	assertTestFont(t, f)
+}
+
+func TestParseBDFRejectsOtherCharsets(t *testing.T) {
+	bdf := strings.Replace(testBDF, `"ISO10646"`, `"KOI8"`, 1)
+	if _, err := ParseBDF(strings.NewReader(bdf)); err == nil {
+		t.Errorf("Expected an error for a KOI8 font")
+	}
+}
+
+// encodePCF builds a PCF file out of a parsed font, the way bdftopcf would with the given bitmap format
+func encodePCF(f *Font, format uint32) []byte {
+	order := binary.ByteOrder(binary.LittleEndian)
+	if format&pcfByteOrderMsb != 0 {
+		order = binary.BigEndian
+	}
+	var runes []rune
+	for ch := rune(0); ch < 0x10000; ch++ {
+		if _, found := f.glyphs[ch]; found {
+			runes = append(runes, ch)
+		}
+	}
+	table := func(write func(b *bytes.Buffer)) []byte {
+		var b bytes.Buffer
+		binary.Write(&b, binary.LittleEndian, format)
+		write(&b)
+		return b.Bytes()
+	}
+	metrics := table(func(b *bytes.Buffer) {
+		binary.Write(b, order, int32(len(runes)))
+		for _, ch := range runes {
+			g := f.glyphs[ch]
+			binary.Write(b, order, []int16{int16(g.XOffset), int16(g.XOffset + g.Width), int16(g.Advance),
+				int16(g.Height + g.YOffset), int16(-g.YOffset), 0})
+		}
+	})
+	pad := 1 << (format & 3)
+	var data []byte
+	var offsets []int32
+	for _, ch := range runes {
+		g := f.glyphs[ch]
+		offsets = append(offsets, int32(len(data)))
+		rowSize := ((g.Width+7)/8 + pad - 1) / pad * pad
+		for y := 0; y < g.Height; y++ {
+			row := make([]byte, rowSize)
+			for x := 0; x < g.Width; x++ {
+				if g.Pixel(x, y) {
+					if format&pcfBitOrderMsb != 0 {
+						row[x/8] |= 0x80 >> uint(x%8)
+					} else {
+						row[x/8] |= 1 << uint(x%8)
+					}
+				}
+			}
+			data = append(data, row...)
+		}
+	}
+	bitmaps := table(func(b *bytes.Buffer) {
+		binary.Write(b, order, int32(len(runes)))
+		binary.Write(b, order, offsets)
+		sizes := make([]int32, 4)
+		sizes[format&3] = int32(len(data))
+		binary.Write(b, order, sizes)
+		b.Write(data)
+	})
+	encodings := table(func(b *bytes.Buffer) {
+		binary.Write(b, order, []int16{0, 0xFF, 0, 0x04, 0})
+		indices := make([]uint16, 0x500)
+		for i := range indices {
+			indices[i] = 0xFFFF
+		}
+		for i, ch := range runes {
+			indices[ch] = uint16(i)
+		}
+		binary.Write(b, order, indices)
+	})
+	accelerators := table(func(b *bytes.Buffer) {
+		b.Write(make([]byte, 8))
+		binary.Write(b, order, []int32{int32(f.Ascent), int32(f.Descent)})
+	})
+
+	tables := []struct {
+		kind int32
+		data []byte
+	}{{pcfMetrics, metrics}, {pcfBitmaps, bitmaps}, {pcfBdfEncodings, encodings}, {pcfBdfAccelerators, accelerators}}
+	var out bytes.Buffer
+	out.WriteString("\x01fcp")
+	binary.Write(&out, binary.LittleEndian, int32(len(tables)))
+	offset := 8 + 16*len(tables)
+	for _, t := range tables {
+		binary.Write(&out, binary.LittleEndian, []int32{t.kind, int32(format), int32(len(t.data)), int32(offset)})
+		offset += len(t.data)
+	}
+	for _, t := range tables {
+		out.Write(t.data)
+	}
+	return out.Bytes()
+}
+
+func TestParsePCF(t *testing.T) {
+	source, err := ParseBDF(strings.NewReader(testBDF))
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	for _, format := range []uint32{pcfByteOrderMsb | pcfBitOrderMsb | 2, 0} {
+		f, err := ParsePCF(bytes.NewReader(encodePCF(source, format)))
+		if err != nil {
+			t.Fatalf("Failed to parse format 0x%x: %v", format, err)
+		}
+		assertTestFont(t, f)
+	}
+}
+
+func TestLoadFontRegistersByFileName(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "test-6.bdf"), []byte(testBDF), 0600); err != nil {
+		t.Fatalf("Failed to write font: %v", err)
+	}
+	loaded, err := LoadFonts(dir)
+	if err != nil || len(loaded) != 1 {
+		t.Fatalf("Failed to load fonts: %v", err)
+	}
+	f, err := LookupFont("test-6")
+	if err != nil {
+		t.Fatalf("Font is not registered: %v", err)
+	}
+	if f != loaded[0] {
+		t.Errorf("Registered font differs from the loaded one")
+	}
+}
+
+func TestFallbackFonts(t *testing.T) {
+	f, err := ParseBDF(strings.NewReader(testBDF))
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	f.Name = "test-fallback"
+	RegisterFont(f)
+	builtin, _ := LookupFont(BuiltinFont)
+
+	if g, _ := findGlyph(builtin, 'Ж'); g != builtinUnknownGlyph {
+		t.Errorf("Found a glyph without a fallback font")
+	}
+	if err := SetFallbackFonts("test-fallback"); err != nil {
+		t.Fatalf("Failed to set fallback fonts: %v", err)
+	}
+	defer SetFallbackFonts()
+	if g, from := findGlyph(builtin, 'Ж'); from != f || g == builtinUnknownGlyph {
+		t.Errorf("Glyph is not taken from the fallback font")
+	}
+	if _, from := findGlyph(f, 'B'); from != builtin {
+		t.Errorf("Glyph is not taken from the built-in font")
+	}
+	if err := SetFallbackFonts("no-such-font"); err == nil {
+		t.Errorf("Expected an error for an unknown font")
+	}
+}
@@ -1,6 +1,15 @@
 package oled
 
+// Special symbols that have no printable ASCII counterpart, but can be used in printed messages
+const (
+	// SymbolHeart is drawn as a heart
+	SymbolHeart = '\u2665'
+)
+
+// font holds the glyphs of the printable ASCII characters, from space to tilde
 var font [][]byte
+var symbols map[rune][]byte
+var builtinUnknownGlyph *Glyph
 var signalLevels [][]byte
 
 func init() {
@@ -67,11 +76,44 @@ func init() {
 		[]byte{0x00, 0x00, 0xFE, 0x82, 0x00}, // [
 		[]byte{0x02, 0x0C, 0x10, 0x60, 0x80}, // \
 		[]byte{0x00, 0x82, 0xFE, 0x00, 0x00}, // ]
-		[]byte{0x1C, 0x3E, 0xFC, 0x3E, 0x1C}, // ♥ (^)
+		[]byte{0x08, 0x04, 0x02, 0x04, 0x08}, // ^
 		[]byte{0x80, 0x80, 0x80, 0x80, 0x80}, // _
 		[]byte{0x00, 0x02, 0x06, 0x00, 0x00}, // `
-		[]byte{0xFE, 0x82, 0x92, 0x82, 0xFE}, // Unknown
+		[]byte{0x40, 0xA8, 0xA8, 0xA8, 0xF0}, // a
+		[]byte{0xFE, 0x90, 0x88, 0x88, 0x70}, // b
+		[]byte{0x70, 0x88, 0x88, 0x88, 0x40}, // c
+		[]byte{0x70, 0x88, 0x88, 0x90, 0xFE}, // d
+		[]byte{0x70, 0xA8, 0xA8, 0xA8, 0x30}, // e
+		[]byte{0x10, 0xFC, 0x12, 0x02, 0x04}, // f
+		[]byte{0x18, 0xA4, 0xA4, 0xA4, 0x7C}, // g
+		[]byte{0xFE, 0x10, 0x08, 0x08, 0xF0}, // h
+		[]byte{0x00, 0x88, 0xFA, 0x80, 0x00}, // i
+		[]byte{0x40, 0x80, 0x88, 0x7A, 0x00}, // j
+		[]byte{0xFE, 0x20, 0x50, 0x88, 0x00}, // k
+		[]byte{0x00, 0x82, 0xFE, 0x80, 0x00}, // l
+		[]byte{0xF8, 0x08, 0x30, 0x08, 0xF0}, // m
+		[]byte{0xF8, 0x10, 0x08, 0x08, 0xF0}, // n
+		[]byte{0x70, 0x88, 0x88, 0x88, 0x70}, // o
+		[]byte{0xFC, 0x24, 0x24, 0x24, 0x18}, // p
+		[]byte{0x18, 0x24, 0x24, 0x24, 0xFC}, // q
+		[]byte{0xF8, 0x10, 0x08, 0x08, 0x10}, // r
+		[]byte{0x90, 0xA8, 0xA8, 0xA8, 0x40}, // s
+		[]byte{0x08, 0x7E, 0x88, 0x80, 0x40}, // t
+		[]byte{0x78, 0x80, 0x80, 0x40, 0xF8}, // u
+		[]byte{0x38, 0x40, 0x80, 0x40, 0x38}, // v
+		[]byte{0x78, 0x80, 0x60, 0x80, 0x78}, // w
+		[]byte{0x88, 0x50, 0x20, 0x50, 0x88}, // x
+		[]byte{0x1C, 0xA0, 0xA0, 0xA0, 0x7C}, // y
+		[]byte{0x88, 0xC8, 0xA8, 0x98, 0x88}, // z
+		[]byte{0x00, 0x10, 0x6C, 0x82, 0x00}, // {
+		[]byte{0x00, 0x00, 0xFE, 0x00, 0x00}, // |
+		[]byte{0x00, 0x82, 0x6C, 0x10, 0x00}, // }
+		[]byte{0x10, 0x08, 0x10, 0x20, 0x10}, // ~
+	}
+	symbols = map[rune][]byte{
+		SymbolHeart: []byte{0x1C, 0x3E, 0xFC, 0x3E, 0x1C},
 	}
+	builtinUnknownGlyph = columnsGlyph([]byte{0xFE, 0x82, 0x92, 0x82, 0xFE})
 	signalLevels = [][]byte{
 		[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
 		[]byte{0x00, 0x00, 0x00, 0x00, 0x40, 0x20, 0xA0, 0x20, 0x40, 0x00, 0x00, 0x00, 0x00},
@@ -79,4 +121,43 @@ func init() {
 		[]byte{0x10, 0x08, 0x24, 0x12, 0x4A, 0x29, 0xA5, 0x29, 0x4A, 0x12, 0x24, 0x08, 0x10},
 	}
 	SignalLevels = len(signalLevels)
+	SignalLevelWidth = len(signalLevels[0])
+
+	glyphs := make(map[rune]*Glyph)
+	proportional := make(map[rune]*Glyph)
+	for i, columns := range font {
+		glyphs[rune(' '+i)] = columnsGlyph(columns)
+		proportional[rune(' '+i)] = columnsGlyph(trimColumns(columns))
+	}
+	for ch, columns := range symbols {
+		glyphs[ch] = columnsGlyph(columns)
+		proportional[ch] = columnsGlyph(trimColumns(columns))
+	}
+	proportional[' '].Advance = 3
+	RegisterFont(newFont(BuiltinFont, 8, 0, glyphs))
+	RegisterFont(newFont(BuiltinProportionalFont, 8, 0, proportional))
+}
+
+// trimColumns removes the blank columns on both sides of a glyph
+func trimColumns(columns []byte) []byte {
+	first, last := 0, len(columns)-1
+	for first <= last && columns[first] == 0x00 {
+		first++
+	}
+	for last >= first && columns[last] == 0x00 {
+		last--
+	}
+	return columns[first : last+1]
+}
+
+// columnsGlyph converts a glyph of the built-in font into a bitmap
+// Every byte is a column of 8 pixels, least significant bit on top, and a blank column separates the glyphs
+func columnsGlyph(columns []byte) *Glyph {
+	g := &Glyph{Width: len(columns), Height: 8, Advance: len(columns) + 1, bits: make([]bool, len(columns)*8)}
+	for x, bits := range columns {
+		for y := 0; y < 8; y++ {
+			g.bits[y*g.Width+x] = bits&(1<<uint(y)) != 0
+		}
+	}
+	return g
 }
@@ -0,0 +1,35 @@
+package oled
+
+import (
+	"reflect"
+	"testing"
+)
+
+func builtinGlyph(ch rune) *Glyph {
+	font, _ := LookupFont(BuiltinFont)
+	g, _ := findGlyph(font, ch)
+	return g
+}
+
+func TestFontCoversPrintableASCII(t *testing.T) {
+	for ch := ' '; ch <= '~'; ch++ {
+		if builtinGlyph(ch) == builtinUnknownGlyph {
+			t.Errorf("No glyph for %q", ch)
+		}
+	}
+	if reflect.DeepEqual(builtinGlyph('a'), builtinGlyph('A')) {
+		t.Errorf("Lowercase is drawn as uppercase")
+	}
+}
+
+func TestFontSymbols(t *testing.T) {
+	if builtinGlyph(SymbolHeart) == builtinUnknownGlyph {
+		t.Errorf("No glyph for the heart symbol")
+	}
+	if reflect.DeepEqual(builtinGlyph('^'), builtinGlyph(SymbolHeart)) {
+		t.Errorf("Caret is drawn as a heart")
+	}
+	if builtinGlyph('é') != builtinUnknownGlyph {
+		t.Errorf("Expected a box for an unknown character")
+	}
+}
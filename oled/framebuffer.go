@@ -0,0 +1,227 @@
+package oled
+
+import (
+	"image"
+	"image/color"
+	"strings"
+)
+
+const (
+	// Width is the number of pixel columns on the screen
+	Width = 128
+	// Height is the number of pixel rows on the screen
+	Height = 64
+	// Pages is the number of 8 pixel high pages the screen is split into
+	Pages = Height / 8
+)
+
+// Framebuffer holds an in-memory copy of the screen contents
+// Every byte is a vertical strip of 8 pixels within a page, least significant bit on top,
+// which is the layout the controller RAM uses
+// Pixels are addressed in the orientation of the screen, while pages and columns are those of the panel
+type Framebuffer struct {
+	pages       [Pages][Width]byte
+	dirty       [Pages]span
+	orientation Orientation
+}
+
+// span is the range of changed columns of a page, from included to excluded
+type span struct {
+	from, to int
+}
+
+// Size returns the width and the height of the screen in its orientation,
+// which is 64x128 when the screen is rotated by 90 or 270 degrees
+func (fb *Framebuffer) Size() (width, height int) {
+	if fb.orientation.portrait() {
+		return Height, Width
+	}
+	return Width, Height
+}
+
+// Orientation returns the orientation pixels are drawn in
+func (fb *Framebuffer) Orientation() Orientation {
+	return fb.orientation
+}
+
+// SetOrientation changes the orientation pixels are drawn in
+// The picture is turned along when the size of the screen stays the same, and is cleared otherwise
+func (fb *Framebuffer) SetOrientation(o Orientation) error {
+	if err := o.check(); err != nil {
+		return err
+	}
+	if o == fb.orientation {
+		return nil
+	}
+	if o.portrait() != fb.orientation.portrait() {
+		fb.orientation = o
+		fb.Clear()
+		return nil
+	}
+	width, height := fb.Size()
+	picture := NewBitmap(width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			picture.SetPixel(x, y, fb.Pixel(x, y))
+		}
+	}
+	fb.orientation = o
+	fb.DrawBitmap(0, 0, picture)
+	return nil
+}
+
+// Lines returns the number of 8 pixel high lines of text that fit on the screen
+func (fb *Framebuffer) Lines() int {
+	_, height := fb.Size()
+	return height / 8
+}
+
+// lineTop returns the top of the given line of text, line numbers wrap around
+func (fb *Framebuffer) lineTop(line int) int {
+	lines := fb.Lines()
+	return (line%lines + lines) % lines * 8
+}
+
+// physical returns the position on the panel of the pixel at the given position on the screen
+func (fb *Framebuffer) physical(x, y int) (int, int, bool) {
+	width, height := fb.Size()
+	if x < 0 || x >= width || y < 0 || y >= height {
+		return 0, 0, false
+	}
+	px, py := fb.orientation.physical(x, y, width, height)
+	return px, py, true
+}
+
+// Pixel reports whether the pixel at the given position is lit
+func (fb *Framebuffer) Pixel(x, y int) bool {
+	x, y, inside := fb.physical(x, y)
+	if !inside {
+		return false
+	}
+	return fb.pages[y/8][x]&(1<<uint(y%8)) != 0
+}
+
+// SetPixel lights or blanks the pixel at the given position
+// Pixels outside of the screen are ignored
+func (fb *Framebuffer) SetPixel(x, y int, on bool) {
+	x, y, inside := fb.physical(x, y)
+	if !inside {
+		return
+	}
+	bits := fb.pages[y/8][x]
+	if on {
+		bits |= 1 << uint(y%8)
+	} else {
+		bits &^= 1 << uint(y%8)
+	}
+	fb.SetColumn(y/8, x, bits)
+}
+
+// Palette holds the colors of blank and lit pixels in images of the screen
+var Palette = color.Palette{color.Black, color.White}
+
+// Image returns a copy of the picture as it is seen on the screen, in the orientation of the screen
+// Lit pixels are white and blank ones are black
+func (fb *Framebuffer) Image() *image.Paletted {
+	width, height := fb.Size()
+	img := image.NewPaletted(image.Rect(0, 0, width, height), Palette)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if fb.Pixel(x, y) {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+	return img
+}
+
+// ASCII returns the picture as text, one line per row of pixels, with # for lit pixels and . for blank ones
+func (fb *Framebuffer) ASCII() string {
+	width, height := fb.Size()
+	var sb strings.Builder
+	sb.Grow((width + 1) * height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if fb.Pixel(x, y) {
+				sb.WriteByte('#')
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// Column returns the 8 pixel strip at the given page and column of the panel
+func (fb *Framebuffer) Column(page, x int) byte {
+	if page < 0 || page >= Pages || x < 0 || x >= Width {
+		return 0
+	}
+	return fb.pages[page][x]
+}
+
+// SetColumn replaces the 8 pixel strip at the given page and column of the panel
+// Strips outside of the screen are ignored
+func (fb *Framebuffer) SetColumn(page, x int, bits byte) {
+	if page < 0 || page >= Pages || x < 0 || x >= Width {
+		return
+	}
+	if fb.pages[page][x] != bits {
+		fb.pages[page][x] = bits
+		if d := &fb.dirty[page]; d.from == d.to {
+			*d = span{x, x + 1}
+		} else if x < d.from {
+			d.from = x
+		} else if x >= d.to {
+			d.to = x + 1
+		}
+	}
+}
+
+// Page returns a copy of the given page
+func (fb *Framebuffer) Page(page int) []byte {
+	data := make([]byte, Width)
+	if page >= 0 && page < Pages {
+		copy(data, fb.pages[page][:])
+	}
+	return data
+}
+
+// Clear blanks the whole framebuffer
+func (fb *Framebuffer) Clear() {
+	for page := range fb.pages {
+		for x := range fb.pages[page] {
+			fb.SetColumn(page, x, 0x00)
+		}
+	}
+}
+
+// Dirty reports whether the given page has changed since the last flush
+func (fb *Framebuffer) Dirty(page int) bool {
+	return page >= 0 && page < Pages && fb.dirty[page].from != fb.dirty[page].to
+}
+
+// Invalidate marks every page as changed, so the next flush resends the whole screen
+func (fb *Framebuffer) Invalidate() {
+	for page := range fb.dirty {
+		fb.dirty[page] = span{0, Width}
+	}
+}
+
+// flush passes the columns of every page that changed since the last flush to write,
+// from the leftmost changed column to the rightmost one, x being the first of them
+// A page stays dirty if write fails, so it is sent again next time
+func (fb *Framebuffer) flush(write func(page, x int, data []byte) error) error {
+	for page := range fb.pages {
+		d := fb.dirty[page]
+		if d.from == d.to {
+			continue
+		}
+		if err := write(page, d.from, fb.pages[page][d.from:d.to]); err != nil {
+			return err
+		}
+		fb.dirty[page] = span{}
+	}
+	return nil
+}
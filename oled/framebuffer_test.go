@@ -0,0 +1,157 @@
+package oled
+
+import (
+	"image"
+	"testing"
+)
+
+func TestFramebufferPixel(t *testing.T) {
+	var fb Framebuffer
+	fb.SetPixel(5, 10, true)
+	if !fb.Pixel(5, 10) {
+		t.Errorf("Pixel (5, 10) is not lit")
+	}
+	if fb.Column(1, 5) != 0x04 {
+		t.Errorf("Unexpected column value 0x%02x", fb.Column(1, 5))
+	}
+	fb.SetPixel(5, 10, false)
+	if fb.Pixel(5, 10) {
+		t.Errorf("Pixel (5, 10) is still lit")
+	}
+	fb.SetPixel(Width, Height, true)
+	if fb.Pixel(Width, Height) {
+		t.Errorf("Pixel outside of the screen is lit")
+	}
+}
+
+func TestFramebufferFlushesDirtyPagesOnly(t *testing.T) {
+	var fb Framebuffer
+	fb.SetColumn(2, 0, 0xFF)
+	fb.SetColumn(6, 127, 0x01)
+	var flushed []int
+	write := func(page, x int, data []byte) error {
+		flushed = append(flushed, page)
+		return nil
+	}
+	if err := fb.flush(write); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+	if len(flushed) != 2 || flushed[0] != 2 || flushed[1] != 6 {
+		t.Errorf("Unexpected pages flushed: %v", flushed)
+	}
+
+	flushed = nil
+	fb.SetColumn(2, 0, 0xFF)
+	if err := fb.flush(write); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+	if len(flushed) != 0 {
+		t.Errorf("Unchanged pages flushed: %v", flushed)
+	}
+
+	fb.Invalidate()
+	if err := fb.flush(write); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+	if len(flushed) != Pages {
+		t.Errorf("Expected all pages to be flushed, got %v", flushed)
+	}
+}
+
+func TestFramebufferFlushesChangedColumnsOnly(t *testing.T) {
+	var fb Framebuffer
+	fb.SetColumn(1, 40, 0x01)
+	fb.SetColumn(1, 10, 0x02)
+	fb.SetColumn(1, 20, 0x03)
+	fb.SetColumn(1, 10, 0x00)
+	err := fb.flush(func(page, x int, data []byte) error {
+		if page != 1 || x != 10 || len(data) != 31 || data[0] != 0x00 || data[10] != 0x03 || data[30] != 0x01 {
+			t.Errorf("Unexpected columns %d to %d of page %d flushed", x, x+len(data), page)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+}
+
+func TestFramebufferKeepsPageDirtyOnError(t *testing.T) {
+	var fb Framebuffer
+	fb.SetColumn(3, 10, 0x10)
+	err := fb.flush(func(page, x int, data []byte) error {
+		return ErrorScreenClosed
+	})
+	if err != ErrorScreenClosed {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if !fb.Dirty(3) {
+		t.Errorf("Page 3 is not dirty after a failed flush")
+	}
+}
+
+func TestFramebufferOrientation(t *testing.T) {
+	tests := []struct {
+		o          Orientation
+		page, x    int
+		bits       byte
+		width      int
+		lines      int
+		topLeftLit bool
+	}{
+		{Orientation{}, 0, 0, 0x01, Width, 8, true},
+		{Orientation{Rotation: 180}, Pages - 1, Width - 1, 0x80, Width, 8, true},
+		{Orientation{Rotation: 90}, 0, Width - 1, 0x01, Height, 16, true},
+		{Orientation{Rotation: 270}, Pages - 1, 0, 0x80, Height, 16, true},
+		{Orientation{MirrorX: true}, 0, Width - 1, 0x01, Width, 8, true},
+		{Orientation{MirrorY: true}, Pages - 1, 0, 0x80, Width, 8, true},
+	}
+	for _, test := range tests {
+		var fb Framebuffer
+		if err := fb.SetOrientation(test.o); err != nil {
+			t.Fatalf("Failed to set orientation %+v: %v", test.o, err)
+		}
+		fb.SetPixel(0, 0, true)
+		if fb.Column(test.page, test.x) != test.bits {
+			t.Errorf("Top left pixel in orientation %+v is not at page %d, column %d", test.o, test.page, test.x)
+		}
+		if width, _ := fb.Size(); width != test.width {
+			t.Errorf("Unexpected width %d in orientation %+v", width, test.o)
+		}
+		if fb.Lines() != test.lines {
+			t.Errorf("Unexpected number of lines %d in orientation %+v", fb.Lines(), test.o)
+		}
+	}
+}
+
+func TestFramebufferTurnsPicture(t *testing.T) {
+	var fb Framebuffer
+	fb.SetPixel(1, 2, true)
+	if err := fb.SetOrientation(Orientation{Rotation: 180}); err != nil {
+		t.Fatalf("Failed to set orientation: %v", err)
+	}
+	if !fb.Pixel(1, 2) || fb.Column(Pages-1, Width-2) != 0x20 {
+		t.Errorf("Picture is not turned upside down")
+	}
+	if err := fb.SetOrientation(Orientation{Rotation: 90}); err != nil {
+		t.Fatalf("Failed to set orientation: %v", err)
+	}
+	if fb.Pixel(1, 2) {
+		t.Errorf("Picture is not cleared when switching to portrait")
+	}
+	if err := fb.SetOrientation(Orientation{Rotation: 45}); err == nil {
+		t.Errorf("Rotation by 45 degrees is accepted")
+	}
+}
+
+func TestFramebufferImage(t *testing.T) {
+	var fb Framebuffer
+	fb.SetOrientation(Orientation{Rotation: 90})
+	fb.SetPixel(3, 100, true)
+	img := fb.Image()
+	if img.Bounds() != image.Rect(0, 0, Height, Width) {
+		t.Fatalf("Unexpected image bounds %v", img.Bounds())
+	}
+	if img.ColorIndexAt(3, 100) != 1 || img.ColorIndexAt(100, 3) != 0 {
+		t.Errorf("Image does not match the framebuffer")
+	}
+}
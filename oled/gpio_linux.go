@@ -0,0 +1,73 @@
+//go:build linux
+
+package oled
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// Request codes and flags of the GPIO character device, see linux/gpio.h
+const (
+	gpioGetLineHandleIoctl       = 0xC16CB403
+	gpioHandleSetLineValuesIoctl = 0xC040B409
+	gpioHandleRequestOutput      = 1 << 1
+)
+
+type gpioHandleRequest struct {
+	lineOffsets   [64]uint32
+	flags         uint32
+	defaultValues [64]uint8
+	consumerLabel [32]byte
+	lines         uint32
+	fd            int32
+}
+
+type gpioHandleData struct {
+	values [64]uint8
+}
+
+// chardevLine is a GPIO line requested through the GPIO character device
+type chardevLine struct {
+	fd int
+}
+
+// openGpioLine requests a single line of the given chip as an output with the given initial level
+func openGpioLine(chip string, offset int, label string, high bool) (gpioLine, error) {
+	file, err := os.OpenFile(chip, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	req := gpioHandleRequest{flags: gpioHandleRequestOutput, lines: 1}
+	req.lineOffsets[0] = uint32(offset)
+	if high {
+		req.defaultValues[0] = 1
+	}
+	copy(req.consumerLabel[:len(req.consumerLabel)-1], label)
+	if err := ioctl(file.Fd(), gpioGetLineHandleIoctl, unsafe.Pointer(&req)); err != nil {
+		return nil, fmt.Errorf("Failed to request line %d of %s: %v", offset, chip, err)
+	}
+	return &chardevLine{int(req.fd)}, nil
+}
+
+func (l *chardevLine) set(high bool) error {
+	var data gpioHandleData
+	if high {
+		data.values[0] = 1
+	}
+	return ioctl(uintptr(l.fd), gpioHandleSetLineValuesIoctl, unsafe.Pointer(&data))
+}
+
+func (l *chardevLine) Close() error {
+	return syscall.Close(l.fd)
+}
+
+func ioctl(fd, request uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
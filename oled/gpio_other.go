@@ -0,0 +1,9 @@
+//go:build !linux
+
+package oled
+
+import "fmt"
+
+func openGpioLine(chip string, offset int, label string, high bool) (gpioLine, error) {
+	return nil, fmt.Errorf("GPIO character device is only supported on Linux")
+}
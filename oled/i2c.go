@@ -2,157 +2,144 @@ package oled
 
 import (
 	"fmt"
-	"image/color"
-	"image/png"
-	"io"
-	"os"
-	"strings"
+	"path/filepath"
+	"sort"
 
 	"golang.org/x/exp/io/i2c"
 )
 
+// DefaultI2cAddresses lists the addresses OLED modules are usually strapped to
+var DefaultI2cAddresses = []int{0x3c, 0x3d}
+
+const i2cBusPattern = "/dev/i2c-*"
+
 // I2cOpener allows to open a real I2C screen
+// When Bus or Address is not set, the available buses and the default addresses are probed for a screen
 type I2cOpener struct {
+	// Controller is the controller chip of the screen, SH1106 is used when not set
+	Controller Controller
+	// Bus is the path of the I2C bus device, e.g. /dev/i2c-1
+	Bus string
+	// Address is the 7-bit I2C address of the screen, e.g. 0x3c
+	Address int
+	// Orientation tells how the picture is turned on the panel, it can be changed later on the framebuffer
+	Orientation Orientation
+	// MaxTransfer is the largest number of bytes the I2C adapter writes at once, control byte included, no limit when not set
+	// Some adapters only write 32 bytes at once
+	MaxTransfer int
 }
 
-type i2cScreen struct {
-	dev *i2c.Device
+// i2cDevice is a connection to a single device on an I2C bus
+type i2cDevice interface {
+	Write(buf []byte) error
+	Close() error
 }
 
-func (o *I2cOpener) open() (Screen, error) {
-	dev, err := i2c.Open(&i2c.Devfs{Dev: "/dev/i2c-1"}, 0x3c)
-	if err != nil {
-		return nil, err
-	}
+type i2cOpenFunc func(bus string, addr int) (i2cDevice, error)
 
-	screen := &i2cScreen{dev: dev}
-	if err := screen.init(); err != nil {
-		dev.Close()
-		return nil, err
-	}
-	return screen, nil
+func openI2cDevice(bus string, addr int) (i2cDevice, error) {
+	return i2c.Open(&i2c.Devfs{Dev: bus}, addr)
 }
 
-func (s *i2cScreen) init() error {
-	if err := s.dev.Write([]byte{0x00, 0xAE}); err != nil {
-		return fmt.Errorf("Failed to turn off: %v", err)
-	}
-	if err := s.dev.Write([]byte{0x00, 0xA1}); err != nil {
-		return fmt.Errorf("Failed to rotate: %v", err)
-	}
-	if err := s.dev.Write([]byte{0x00, 0xC8}); err != nil {
-		return fmt.Errorf("Failed to flip: %v", err)
-	}
-	if err := s.dev.Write([]byte{0x00, 0x40}); err != nil {
-		return fmt.Errorf("Failed to set offset: %v", err)
-	}
-	if err := s.Clear(); err != nil {
-		return fmt.Errorf("Failed to clean: %v", err)
+func (o *I2cOpener) open() (Screen, error) {
+	if o.MaxTransfer == 1 || o.MaxTransfer < 0 {
+		return nil, fmt.Errorf("I2C transfers should take at least 2 bytes")
 	}
-	if err := s.dev.Write([]byte{0x00, 0xAF}); err != nil {
-		return fmt.Errorf("Failed to turn on: %v", err)
+	bus, addr, dev, err := probeI2c(o.Bus, o.Address, i2cBusPattern, openI2cDevice)
+	if err != nil {
+		return nil, err
 	}
-	return nil
-}
-
-func (s *i2cScreen) Close() error {
-	return s.dev.Close()
+	return newControllerScreen(&i2cTransport{dev, o.MaxTransfer}, o.Controller, o.Orientation, fmt.Sprintf("%s at 0x%02x", bus, addr))
 }
 
-func (s *i2cScreen) Clear() error {
-	const width = 132
-	emptyLine := make([]byte, width+1)
-	for i := range emptyLine {
-		emptyLine[i] = 0x00
-	}
-	emptyLine[0] = 0x40
-	for i := 0; i < 8; i++ {
-		if err := s.dev.Write([]byte{0x00, 0xB0 + byte(i&0x7), 0x02, 0x10}); err != nil {
-			return err
+// probeI2c finds a device that acknowledges a no-op command
+// An empty bus means every bus matching busPattern, a zero address means every default address
+func probeI2c(bus string, addr int, busPattern string, open i2cOpenFunc) (string, int, i2cDevice, error) {
+	buses := []string{bus}
+	if bus == "" {
+		var err error
+		if buses, err = filepath.Glob(busPattern); err != nil {
+			return "", 0, nil, err
 		}
-		if err := s.dev.Write(emptyLine); err != nil {
-			return err
+		sort.Strings(buses)
+	}
+	addresses := []int{addr}
+	if addr == 0 {
+		addresses = DefaultI2cAddresses
+	}
+	var lastErr error
+	for _, b := range buses {
+		for _, a := range addresses {
+			dev, err := open(b, a)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			if err := dev.Write([]byte{0x00, 0xE3}); err != nil {
+				dev.Close()
+				lastErr = err
+				continue
+			}
+			return b, a, dev, nil
 		}
 	}
-	return nil
-}
-
-func (s *i2cScreen) Print(line int, offset int, message string) error {
-	if err := s.dev.Write([]byte{0x00, 0xB0 | byte(line&0x7), byte((offset + 2) & 0x07), 0x10 | byte(((offset+2)>>4)&0x07)}); err != nil {
-		return fmt.Errorf("Failed to set page and offset: %v", err)
-	}
-	if len(message) > 21 {
-		message = message[:21]
+	if lastErr == nil {
+		return "", 0, nil, fmt.Errorf("No I2C buses match %s", busPattern)
 	}
-	for _, ch := range strings.ToUpper(message) {
-		var letter []byte
-		if ch < ' ' || int(ch-' ') >= len(font) {
-			letter = font[len(font)-1]
-		} else {
-			letter = font[ch-' ']
-		}
-		if err := s.dev.Write(append(append([]byte{0x40}, letter...), 0x00)); err != nil {
-			return fmt.Errorf("Failed to print %c: %v", ch, err)
-		}
+	if len(buses) == 1 && len(addresses) == 1 {
+		return "", 0, nil, lastErr
 	}
-	return nil
+	return "", 0, nil, fmt.Errorf("No screen found on I2C buses %v: %v", buses, lastErr)
 }
 
-func (s *i2cScreen) DisplaySignalLevel(line int, offset int, level int) error {
-	if err := s.dev.Write([]byte{0x00, 0xB0 | byte(line&0x7), byte((offset + 2) & 0x07), 0x10 | byte(((offset+2)>>4)&0x07)}); err != nil {
-		return fmt.Errorf("Failed to set page and offset: %v", err)
-	}
-	if level >= len(signalLevels) {
-		level = len(signalLevels) - 1
-	}
-	if level < 0 {
-		level = 0
-	}
-	if err := s.dev.Write(append([]byte{0x40}, signalLevels[level]...)); err != nil {
-		return fmt.Errorf("Failed to display signal level: %v", err)
-	}
-	return nil
+// i2cTransport prefixes every write with control bytes that tell commands from data
+// RAM writes take at most max bytes each, control bytes included, when max is set
+type i2cTransport struct {
+	dev i2cDevice
+	max int
 }
 
-func (s *i2cScreen) DisplayImageFile(filepath string) error {
-	file, err := os.Open(filepath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-	return s.DisplayImage(file)
+func (t *i2cTransport) Command(cmds ...byte) error {
+	return t.dev.Write(append([]byte{0x00}, cmds...))
 }
 
-func (s *i2cScreen) DisplayImage(reader io.Reader) error {
-	img, err := png.Decode(reader)
-	if err != nil {
-		return err
-	}
-	rect := img.Bounds()
-	if rect.Dx() != 128 || rect.Dy() != 64 {
-		return fmt.Errorf("Image should have size 128x64")
+func (t *i2cTransport) Data(payload []byte) error {
+	return t.CommandData(nil, payload)
+}
+
+// CommandData sends the commands, each after a control byte that tells more control bytes follow,
+// and the payload for the controller RAM in a single write
+// The part of the payload that does not fit goes on in as few writes as max allows
+func (t *i2cTransport) CommandData(cmds []byte, payload []byte) error {
+	buf := make([]byte, 0, 2*len(cmds)+1+len(payload))
+	for _, cmd := range cmds {
+		buf = append(buf, 0x80, cmd)
+	}
+	buf = append(buf, 0x40)
+	if t.max > 0 && len(buf) >= t.max && len(payload) > 0 {
+		// No room for the data next to the commands
+		if err := t.Command(cmds...); err != nil {
+			return err
+		}
+		buf = buf[:0]
+		buf = append(buf, 0x40)
 	}
-	var i byte
-	for y := rect.Min.Y; y < rect.Max.Y; y += 8 {
-		if err := s.dev.Write([]byte{0x00, 0xB0 + i, 0x02, 0x10}); err != nil {
-			return fmt.Errorf("Failed to set page: %v", err)
+	for {
+		n := len(payload)
+		if t.max > 0 && len(buf)+n > t.max {
+			n = t.max - len(buf)
 		}
-		var ts []byte
-		for x := rect.Min.X; x < rect.Max.X; x++ {
-			var t byte
-			for yy := 7; yy >= 0; yy-- {
-				c := color.GrayModel.Convert(img.At(x, y+yy)).(color.Gray)
-				t <<= 1
-				if c.Y < 0x80 {
-					t |= 1
-				}
-			}
-			ts = append(ts, t)
+		if err := t.dev.Write(append(buf, payload[:n]...)); err != nil {
+			return err
 		}
-		if err := s.dev.Write(append(append([]byte{0x40}, ts...), 0x00)); err != nil {
-			return fmt.Errorf("Failed to output: %v", err)
+		if payload = payload[n:]; len(payload) == 0 {
+			return nil
 		}
-		i++
+		buf = buf[:0]
+		buf = append(buf, 0x40)
 	}
-	return nil
+}
+
+func (t *i2cTransport) Close() error {
+	return t.dev.Close()
 }
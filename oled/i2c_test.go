@@ -2,14 +2,16 @@ package oled
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
 func TestI2C(t *testing.T) {
 	dev, err := Open(&I2cOpener{})
 	if err != nil {
-		fmt.Printf("Failed to open: %v", err)
-		t.FailNow()
+		t.Skipf("No I2C screen connected: %v", err)
 	}
 	if dev == nil {
 		fmt.Print("Open returned nil")
@@ -20,4 +22,136 @@ func TestI2C(t *testing.T) {
 	dev.Print(1, 0, "OVER THE LAZY DOG")
 	dev.Print(2, 0, "!\"#$%&'()*+,-./\\[]^")
 	dev.Print(3, 0, "0123456789:;<=>?_`@")
+	dev.Print(4, 0, "quick brown fox jumps")
+	dev.Print(5, 0, "over the lazy dog {|}~")
+	dev.Print(6, 0, "I "+string(SymbolHeart)+" OLED")
+	dev.Flush()
+}
+
+type fakeI2cDevice struct {
+	present bool
+	closed  bool
+}
+
+func (d *fakeI2cDevice) Write(buf []byte) error {
+	if !d.present {
+		return fmt.Errorf("remote I/O error")
+	}
+	return nil
+}
+
+func (d *fakeI2cDevice) Close() error {
+	d.closed = true
+	return nil
+}
+
+func fakeDeviceTree(t *testing.T, buses ...string) string {
+	dir := t.TempDir()
+	for _, bus := range buses {
+		if err := os.WriteFile(filepath.Join(dir, bus), nil, 0600); err != nil {
+			t.Fatalf("Failed to create %s: %v", bus, err)
+		}
+	}
+	return filepath.Join(dir, "i2c-*")
+}
+
+func fakeI2cOpen(bus string, addr int) i2cOpenFunc {
+	return func(b string, a int) (i2cDevice, error) {
+		return &fakeI2cDevice{present: filepath.Base(b) == bus && a == addr}, nil
+	}
+}
+
+func TestI2cProbeFindsScreen(t *testing.T) {
+	pattern := fakeDeviceTree(t, "i2c-0", "i2c-1", "i2c-2")
+	bus, addr, dev, err := probeI2c("", 0, pattern, fakeI2cOpen("i2c-2", 0x3d))
+	if err != nil {
+		t.Fatalf("Failed to probe: %v", err)
+	}
+	if filepath.Base(bus) != "i2c-2" || addr != 0x3d {
+		t.Errorf("Unexpected device %s at 0x%02x", bus, addr)
+	}
+	if dev.(*fakeI2cDevice).closed {
+		t.Errorf("Found device is closed")
+	}
+}
+
+func TestI2cProbeUsesGivenAddress(t *testing.T) {
+	pattern := fakeDeviceTree(t, "i2c-0", "i2c-1")
+	_, _, _, err := probeI2c("", 0x3c, pattern, fakeI2cOpen("i2c-1", 0x3d))
+	if err == nil {
+		t.Errorf("Found a device at an address that was not requested")
+	}
+	bus, _, _, err := probeI2c("", 0x3d, pattern, fakeI2cOpen("i2c-1", 0x3d))
+	if err != nil || filepath.Base(bus) != "i2c-1" {
+		t.Errorf("Unexpected result %s: %v", bus, err)
+	}
+}
+
+func TestI2cProbeFailsWithoutBuses(t *testing.T) {
+	pattern := fakeDeviceTree(t)
+	if _, _, _, err := probeI2c("", 0, pattern, fakeI2cOpen("i2c-1", 0x3c)); err == nil {
+		t.Errorf("Found a device without buses")
+	}
+}
+
+// recordingI2cDevice keeps every write
+type recordingI2cDevice struct {
+	writes [][]byte
+}
+
+func (d *recordingI2cDevice) Write(buf []byte) error {
+	d.writes = append(d.writes, append([]byte{}, buf...))
+	return nil
+}
+
+func (d *recordingI2cDevice) Close() error {
+	return nil
+}
+
+// String dumps the writes, one per line
+func (d *recordingI2cDevice) String() string {
+	var sb strings.Builder
+	for _, w := range d.writes {
+		fmt.Fprintf(&sb, "% x\n", w)
+	}
+	return sb.String()
+}
+
+func TestI2cPageUpdateIsSingleWrite(t *testing.T) {
+	dev := &recordingI2cDevice{}
+	scr, err := newControllerScreen(&i2cTransport{dev, 0}, SH1106, Orientation{}, "test")
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	dev.writes = nil
+	scr.Print(3, 0, "Hi")
+	scr.Flush()
+	if len(dev.writes) != 1 || !strings.HasPrefix(dev.String(), "80 b3 80 02 80 10 40 fe 10 10 10 fe") {
+		t.Errorf("Expected a single write of the address and the data, got\n%s", dev)
+	}
+}
+
+func TestI2cWritesFitMaxTransfer(t *testing.T) {
+	dev := &recordingI2cDevice{}
+	tr := &i2cTransport{dev, 32}
+	if err := tr.CommandData([]byte{0xB0, 0x02, 0x10}, make([]byte, Width)); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	var sizes []int
+	for _, w := range dev.writes {
+		sizes = append(sizes, len(w))
+	}
+	if fmt.Sprint(sizes) != "[32 32 32 32 11]" {
+		t.Errorf("Unexpected writes of %v bytes", sizes)
+	}
+	if dev.writes[1][0] != 0x40 || dev.writes[0][6] != 0x40 {
+		t.Errorf("Writes do not start with control bytes")
+	}
+
+	dev.writes = nil
+	tr = &i2cTransport{dev, 4}
+	tr.CommandData([]byte{0xB0, 0x02, 0x10}, []byte{1, 2, 3})
+	if dev.String() != "00 b0 02 10\n40 01 02 03\n" {
+		t.Errorf("Commands that leave no room for data are not sent on their own:\n%s", dev)
+	}
 }
@@ -0,0 +1,145 @@
+package oled
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MaxIconSize is the largest width and height of an icon, in pixels
+const MaxIconSize = 64
+
+// builtinIcons holds the icons that are compiled into the package
+// Every byte is a column of 8 pixels, least significant bit on top
+var builtinIcons = map[string][]byte{
+	"battery-empty": []byte{0x7E, 0x42, 0x42, 0x42, 0x42, 0x42, 0x42, 0x42, 0x42, 0x7E, 0x18},
+	"battery-low":   []byte{0x7E, 0x42, 0x7E, 0x7E, 0x42, 0x42, 0x42, 0x42, 0x42, 0x7E, 0x18},
+	"battery-half":  []byte{0x7E, 0x42, 0x7E, 0x7E, 0x7E, 0x42, 0x42, 0x42, 0x42, 0x7E, 0x18},
+	"battery-full":  []byte{0x7E, 0x42, 0x7E, 0x7E, 0x7E, 0x7E, 0x7E, 0x7E, 0x42, 0x7E, 0x18},
+	"arrow-up":      []byte{0x08, 0x04, 0x02, 0x7F, 0x02, 0x04, 0x08},
+	"arrow-down":    []byte{0x08, 0x10, 0x20, 0x7F, 0x20, 0x10, 0x08},
+	"arrow-left":    []byte{0x08, 0x1C, 0x2A, 0x49, 0x08, 0x08, 0x08},
+	"arrow-right":   []byte{0x08, 0x08, 0x08, 0x49, 0x2A, 0x1C, 0x08},
+	"check":         []byte{0x18, 0x30, 0x60, 0x30, 0x18, 0x0C, 0x06},
+	"cross":         []byte{0x41, 0x22, 0x14, 0x08, 0x14, 0x22, 0x41},
+	"warning":       []byte{0xC0, 0xF0, 0xF8, 0xFE, 0xA3, 0xFE, 0xF8, 0xF0, 0xC0},
+	"bell":          []byte{0x20, 0x3C, 0x3E, 0xBF, 0x3E, 0x3C, 0x20},
+	"sun":           []byte{0x08, 0x22, 0x1C, 0x5D, 0x1C, 0x22, 0x08},
+	"cloud":         []byte{0x30, 0x48, 0x44, 0x42, 0x42, 0x44, 0x44, 0x48, 0x30},
+	"rain":          []byte{0x88, 0x54, 0x12, 0x91, 0x51, 0x12, 0x92, 0x54, 0x08},
+	"snow":          []byte{0x08, 0x14, 0x52, 0x11, 0x91, 0x12, 0x52, 0x14, 0x08},
+	"storm":         []byte{0x88, 0x6E, 0x3F, 0x1B, 0x09},
+}
+
+var iconsMutex = &sync.Mutex{}
+var icons = map[string]*Bitmap{}
+
+func init() {
+	for name, columns := range builtinIcons {
+		icons[name] = columnsBitmap(columns)
+	}
+	for level, columns := range signalLevels {
+		icons[fmt.Sprintf("wifi-%d", level)] = columnsBitmap(columns)
+	}
+}
+
+// columnsBitmap converts columns of 8 pixels, least significant bit on top, into a bitmap
+func columnsBitmap(columns []byte) *Bitmap {
+	b := NewBitmap(len(columns), 8)
+	for x, bits := range columns {
+		for y := 0; y < 8; y++ {
+			b.SetPixel(x, y, bits&(1<<uint(y)) != 0)
+		}
+	}
+	return b
+}
+
+// validIconName reports whether the name is made of lowercase letters, digits, dashes and underscores only
+func validIconName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, ch := range name {
+		if (ch < 'a' || ch > 'z') && (ch < '0' || ch > '9') && ch != '-' && ch != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// RegisterIcon makes a copy of the bitmap available by the name, replacing an icon with the same name
+// Names are made of lowercase letters, digits, dashes and underscores, and icons are at most MaxIconSize pixels wide and high
+func RegisterIcon(name string, b *Bitmap) error {
+	if !validIconName(name) {
+		return fmt.Errorf("Invalid icon name %q", name)
+	}
+	if b.Width < 1 || b.Width > MaxIconSize || b.Height < 1 || b.Height > MaxIconSize {
+		return fmt.Errorf("Icon should be from 1x1 to %dx%d pixels", MaxIconSize, MaxIconSize)
+	}
+	icon := &Bitmap{Width: b.Width, Height: b.Height, bits: append([]bool{}, b.bits...)}
+	iconsMutex.Lock()
+	defer iconsMutex.Unlock()
+	icons[name] = icon
+	return nil
+}
+
+// LookupIcon returns a registered icon given its name
+func LookupIcon(name string) (*Bitmap, error) {
+	iconsMutex.Lock()
+	defer iconsMutex.Unlock()
+	if icon, found := icons[name]; found {
+		return icon, nil
+	}
+	return nil, fmt.Errorf("Unknown icon %q", name)
+}
+
+// IconNames returns the names of all registered icons in alphabetical order
+func IconNames() []string {
+	iconsMutex.Lock()
+	defer iconsMutex.Unlock()
+	names := make([]string, 0, len(icons))
+	for name := range icons {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DrawIcon copies the registered icon with its top left corner at (x, y)
+func (fb *Framebuffer) DrawIcon(x, y int, name string) error {
+	icon, err := LookupIcon(name)
+	if err != nil {
+		return err
+	}
+	fb.DrawBitmap(x, y, icon)
+	return nil
+}
+
+// inlineIcon returns the icon written as :name: at the beginning of the text and the length of its name with the colons
+// Text that does not start with the name of a registered icon between colons is not an icon
+func inlineIcon(text string) (*Bitmap, int) {
+	if !strings.HasPrefix(text, ":") {
+		return nil, 0
+	}
+	end := strings.IndexByte(text[1:], ':')
+	if end < 0 || !validIconName(text[1:end+1]) {
+		return nil, 0
+	}
+	icon, err := LookupIcon(text[1 : end+1])
+	if err != nil {
+		return nil, 0
+	}
+	return icon, end + 2
+}
+
+// iconGlyph turns the icon into a glyph whose top is level with the top of a line of text in the font
+func iconGlyph(icon *Bitmap, font *Font) *Glyph {
+	return &Glyph{
+		Width:   icon.Width,
+		Height:  icon.Height,
+		YOffset: font.Ascent - icon.Height,
+		Advance: icon.Width + 1,
+		bits:    icon.bits,
+	}
+}
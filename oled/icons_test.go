@@ -0,0 +1,91 @@
+package oled
+
+import "testing"
+
+func TestBuiltinIcons(t *testing.T) {
+	names := IconNames()
+	for _, name := range []string{"battery-full", "wifi-0", "wifi-3", "arrow-up", "check", "cross", "warning", "bell", "sun", "rain"} {
+		icon, err := LookupIcon(name)
+		if err != nil {
+			t.Errorf("No built-in icon %q among %v", name, names)
+			continue
+		}
+		lit := 0
+		for i := range icon.bits {
+			if icon.bits[i] {
+				lit++
+			}
+		}
+		if lit == 0 {
+			t.Errorf("Icon %q is blank", name)
+		}
+	}
+	if _, err := LookupIcon("no-such-icon"); err == nil {
+		t.Errorf("Expected an error for an unknown icon")
+	}
+}
+
+func TestRegisterIcon(t *testing.T) {
+	b := NewBitmap(2, 3)
+	b.SetPixel(1, 2, true)
+	if err := RegisterIcon("test-dot", b); err != nil {
+		t.Fatalf("Failed to register icon: %v", err)
+	}
+	b.SetPixel(0, 0, true)
+	icon, err := LookupIcon("test-dot")
+	if err != nil {
+		t.Fatalf("Registered icon is not found: %v", err)
+	}
+	if icon.Width != 2 || icon.Height != 3 || !icon.Pixel(1, 2) || icon.Pixel(0, 0) {
+		t.Errorf("Registered icon does not keep the picture it was registered with")
+	}
+	for _, name := range []string{"", "Dot", "a:b", "a b"} {
+		if err := RegisterIcon(name, b); err == nil {
+			t.Errorf("Expected an error for the name %q", name)
+		}
+	}
+	for _, size := range [][2]int{{0, 1}, {1, 0}, {MaxIconSize + 1, 1}} {
+		if err := RegisterIcon("test-size", NewBitmap(size[0], size[1])); err == nil {
+			t.Errorf("Expected an error for a %dx%d icon", size[0], size[1])
+		}
+	}
+}
+
+func TestDrawIcon(t *testing.T) {
+	b := NewBitmap(2, 3)
+	b.SetPixel(1, 2, true)
+	RegisterIcon("test-draw", b)
+	var fb Framebuffer
+	if err := fb.DrawIcon(10, 20, "test-draw"); err != nil {
+		t.Fatalf("Failed to draw icon: %v", err)
+	}
+	assertLit(t, &fb, [2]int{11, 22})
+	if err := fb.DrawIcon(0, 0, "no-such-icon"); err == nil {
+		t.Errorf("Expected an error for an unknown icon")
+	}
+}
+
+func TestInlineIcons(t *testing.T) {
+	b := NewBitmap(3, 2)
+	b.SetPixel(0, 0, true)
+	RegisterIcon("test-inline", b)
+	width, _ := MeasureText("a:test-inline:b", TextStyle{})
+	// a: 6, icon: 3+1, b: 6
+	if width != 16 {
+		t.Errorf("Unexpected width %d of text with an icon", width)
+	}
+	width, _ = MeasureText("12:30:45 :no-such-icon:", TextStyle{})
+	if width != 23*6 {
+		t.Errorf("Text that names no icon is not printed as is, it is %d pixels wide", width)
+	}
+	fit, _ := FitText("ab:test-inline:c", TextStyle{}, 14)
+	if fit != "ab" {
+		t.Errorf("Icon is cut in %q", fit)
+	}
+	var fb Framebuffer
+	fb.DrawText(0, 8, ":test-inline:", TextStyle{})
+	assertLit(t, &fb, [2]int{0, 8})
+	fb.Clear()
+	fb.DrawText(0, 8, ":test-inline:", TextStyle{Scale: 2})
+	assertLit(t, &fb, [2]int{0, 8}, [2]int{1, 8}, [2]int{0, 9}, [2]int{1, 9})
+}
@@ -1,22 +1,33 @@
 package oled
 
 import (
+	"fmt"
 	"io"
 	"log"
+	"os"
 )
 
 // MockOpener allows to open a screen object that does not perform any real connection
-// Can be used for testing
+// The mock screen draws into its framebuffer the same way a real screen does,
+// so tests can check the picture with Framebuffer().Image() or Framebuffer().ASCII()
 type MockOpener struct {
+	// Orientation is the orientation the framebuffer starts in
+	Orientation Orientation
 }
 
 type mockScreen struct {
-	open bool
+	open     bool
+	fb       Framebuffer
+	settings DisplaySettings
 }
 
 func (o *MockOpener) open() (Screen, error) {
 	screen := &mockScreen{}
+	if err := screen.fb.SetOrientation(o.Orientation); err != nil {
+		return nil, err
+	}
 	screen.open = true
+	screen.settings = DisplaySettings{Contrast: 0x80, On: true}
 	log.Printf("Mock screen opened")
 	return screen, nil
 }
@@ -25,10 +36,63 @@ func (o *mockScreen) Clear() error {
 	if !o.open {
 		return ErrorScreenClosed
 	}
+	o.fb.Clear()
 	log.Printf("Mock screen cleared")
 	return nil
 }
 
+func (o *mockScreen) Flush() error {
+	if !o.open {
+		return ErrorScreenClosed
+	}
+	log.Printf("Mock screen flushed")
+	return o.fb.flush(func(page, x int, data []byte) error {
+		return nil
+	})
+}
+
+func (o *mockScreen) Framebuffer() *Framebuffer {
+	return &o.fb
+}
+
+func (o *mockScreen) SetContrast(contrast int) error {
+	if !o.open {
+		return ErrorScreenClosed
+	}
+	if contrast < 0 || contrast > 255 {
+		return fmt.Errorf("Contrast should be between 0 and 255")
+	}
+	o.settings.Contrast = contrast
+	log.Printf("Mock screen contrast is now %d", contrast)
+	return nil
+}
+
+func (o *mockScreen) SetInverted(inverted bool) error {
+	if !o.open {
+		return ErrorScreenClosed
+	}
+	o.settings.Inverted = inverted
+	log.Printf("Mock screen inversion is now %t", inverted)
+	return nil
+}
+
+func (o *mockScreen) SetPower(on bool) error {
+	if !o.open {
+		return ErrorScreenClosed
+	}
+	o.settings.On = on
+	log.Printf("Mock screen power is now %t", on)
+	return nil
+}
+
+func (o *mockScreen) DisplaySettings() DisplaySettings {
+	return o.settings
+}
+
+func (o *mockScreen) Description() string {
+	return "Mock screen"
+}
+
 func (o *mockScreen) Close() error {
 	if !o.open {
 		log.Printf("Attempt to close an already closed screen")
@@ -42,7 +106,15 @@ func (o *mockScreen) Print(line int, offset int, message string) error {
 		return ErrorScreenClosed
 	}
 	log.Printf("Mock screen is now displaying message \"%s\" at line %d, offset %d", message, line, offset)
-	return nil
+	return o.fb.printStyled(line, offset, message, TextStyle{})
+}
+
+func (o *mockScreen) PrintStyled(line int, offset int, message string, style TextStyle) error {
+	if !o.open {
+		return ErrorScreenClosed
+	}
+	log.Printf("Mock screen is now displaying message \"%s\" in font \"%s\" at line %d, offset %d", message, style.Font, line, offset)
+	return o.fb.printStyled(line, offset, message, style)
 }
 
 func (o *mockScreen) DisplaySignalLevel(line int, offset int, level int) error {
@@ -50,6 +122,7 @@ func (o *mockScreen) DisplaySignalLevel(line int, offset int, level int) error {
 		return ErrorScreenClosed
 	}
 	log.Printf("Mock screen is now displaying signal level %d at line %d, offset %d", level, line, offset)
+	o.fb.signalLevel(line, offset, level)
 	return nil
 }
 
@@ -58,7 +131,12 @@ func (o *mockScreen) DisplayImageFile(filepath string) error {
 		return ErrorScreenClosed
 	}
 	log.Printf("Mock screen is now displaying image \"%s\"", filepath)
-	return nil
+	file, err := os.Open(filepath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return o.fb.displayImage(file, ImageStyle{})
 }
 
 func (o *mockScreen) DisplayImage(reader io.Reader) error {
@@ -66,5 +144,13 @@ func (o *mockScreen) DisplayImage(reader io.Reader) error {
 		return ErrorScreenClosed
 	}
 	log.Printf("Mock screen is now displaying image from the provided reader")
-	return nil
+	return o.fb.displayImage(reader, ImageStyle{})
+}
+
+func (o *mockScreen) DisplayImageStyled(reader io.Reader, style ImageStyle) error {
+	if !o.open {
+		return ErrorScreenClosed
+	}
+	log.Printf("Mock screen is now displaying image from the provided reader with %v dithering", style.Dither)
+	return o.fb.displayImage(reader, style)
 }
@@ -2,6 +2,8 @@ package oled
 
 import (
 	"fmt"
+	"image"
+	"strings"
 	"testing"
 )
 
@@ -17,3 +19,74 @@ func TestMock(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func TestMockRendersLikeScreen(t *testing.T) {
+	mock, err := Open(&MockOpener{})
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	scr, _ := openRecording(t, SH1106)
+	for _, s := range []Screen{mock, scr} {
+		s.PrintStyled(1, 3, "Hello", TextStyle{Scale: 2})
+		s.DisplaySignalLevel(5, 100, 3)
+	}
+	if mock.Framebuffer().ASCII() != scr.Framebuffer().ASCII() {
+		t.Errorf("Mock screen shows:\n%s\nScreen shows:\n%s", mock.Framebuffer().ASCII(), scr.Framebuffer().ASCII())
+	}
+	if mock.Framebuffer().Image().ColorIndexAt(3, 8) != 0 {
+		t.Errorf("Unexpected lit pixel")
+	}
+}
+
+func TestMockRendersImage(t *testing.T) {
+	mock, err := Open(&MockOpener{})
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	if err := mock.DisplayImageStyled(strings.NewReader("P1\n2 1\n1 0\n"), ImageStyle{Placement: PlaceCenter}); err != nil {
+		t.Fatalf("Failed to display image: %v", err)
+	}
+	rows := strings.Split(mock.Framebuffer().ASCII(), "\n")
+	if row := rows[(Height-1)/2]; row[Width/2-1:Width/2+1] != "#." || strings.Count(row, "#") != 1 {
+		t.Errorf("Unexpected middle row %q", row)
+	}
+	if err := mock.DisplayImage(strings.NewReader("not an image")); err == nil {
+		t.Errorf("Invalid image is accepted")
+	}
+}
+
+func TestMirrorShowsChanges(t *testing.T) {
+	var shown []*image.Paletted
+	scr, err := Open(&MirrorOpener{Opener: &MockOpener{}, Show: func(img *image.Paletted) {
+		shown = append(shown, img)
+	}})
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	scr.Framebuffer().SetPixel(4, 5, true)
+	scr.Flush()
+	scr.Flush()
+	scr.SetInverted(true)
+	if len(shown) != 3 {
+		t.Fatalf("Unexpected number of pictures shown %d", len(shown))
+	}
+	if shown[1].ColorIndexAt(4, 5) != 1 || shown[2].ColorIndexAt(4, 5) != 0 || shown[2].ColorIndexAt(0, 0) != 1 {
+		t.Errorf("Pictures shown do not match the screen")
+	}
+}
+
+func TestVirtualShowsChanges(t *testing.T) {
+	var shown []*image.Paletted
+	scr, err := Open(&VirtualOpener{Show: func(img *image.Paletted) {
+		shown = append(shown, img)
+	}})
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	scr.Framebuffer().SetPixel(4, 5, true)
+	scr.Flush()
+	scr.Flush()
+	if len(shown) != 2 || shown[1].ColorIndexAt(4, 5) != 1 {
+		t.Errorf("Unexpected pictures shown")
+	}
+}
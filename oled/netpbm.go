@@ -0,0 +1,172 @@
+package oled
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+func init() {
+	image.RegisterFormat("pbm", "P1", decodeNetpbm, decodeNetpbmConfig)
+	image.RegisterFormat("pbm", "P4", decodeNetpbm, decodeNetpbmConfig)
+	image.RegisterFormat("pgm", "P2", decodeNetpbm, decodeNetpbmConfig)
+	image.RegisterFormat("pgm", "P5", decodeNetpbm, decodeNetpbmConfig)
+}
+
+// netpbmHeader is the header of a PBM or PGM image
+type netpbmHeader struct {
+	magic         string
+	width, height int
+	maxValue      int
+}
+
+// netpbmInt reads a decimal number, skipping the whitespace and the comments before it
+func netpbmInt(r *bufio.Reader) (int, error) {
+	value, digits := 0, 0
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			if err == io.EOF && digits > 0 {
+				return value, nil
+			}
+			return 0, err
+		}
+		switch {
+		case b >= '0' && b <= '9':
+			value = value*10 + int(b-'0')
+			digits++
+			if value > 1<<24 {
+				return 0, fmt.Errorf("number is too large")
+			}
+		case digits > 0:
+			r.UnreadByte()
+			return value, nil
+		case b == '#':
+			if _, err := r.ReadString('\n'); err != nil {
+				return 0, err
+			}
+		case b != ' ' && b != '\t' && b != '\n' && b != '\r':
+			return 0, fmt.Errorf("unexpected character %q", b)
+		}
+	}
+}
+
+func readNetpbmHeader(r *bufio.Reader) (netpbmHeader, error) {
+	var h netpbmHeader
+	magic := make([]byte, 2)
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return h, err
+	}
+	h.magic = string(magic)
+	if h.magic != "P1" && h.magic != "P2" && h.magic != "P4" && h.magic != "P5" {
+		return h, fmt.Errorf("not a PBM or PGM image")
+	}
+	var err error
+	if h.width, err = netpbmInt(r); err != nil {
+		return h, err
+	}
+	if h.height, err = netpbmInt(r); err != nil {
+		return h, err
+	}
+	h.maxValue = 1
+	if h.magic == "P2" || h.magic == "P5" {
+		if h.maxValue, err = netpbmInt(r); err != nil {
+			return h, err
+		}
+		if h.maxValue < 1 || h.maxValue > 65535 {
+			return h, fmt.Errorf("invalid maximum gray value %d", h.maxValue)
+		}
+	}
+	if h.width < 1 || h.height < 1 {
+		return h, fmt.Errorf("invalid image size %dx%d", h.width, h.height)
+	}
+	if h.width*h.height > 1<<24 {
+		return h, fmt.Errorf("image is too large")
+	}
+	// binary data starts after a single whitespace character
+	if h.magic == "P4" || h.magic == "P5" {
+		if _, err := r.ReadByte(); err != nil {
+			return h, err
+		}
+	}
+	return h, nil
+}
+
+func decodeNetpbmConfig(reader io.Reader) (image.Config, error) {
+	h, err := readNetpbmHeader(bufio.NewReader(reader))
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{ColorModel: color.GrayModel, Width: h.width, Height: h.height}, nil
+}
+
+// decodeNetpbm reads a PBM or PGM image, in either plain or raw form
+// The black pixels of a PBM image are 1s, the gray levels of a PGM image go from black at 0 to white at the maximum value
+func decodeNetpbm(reader io.Reader) (image.Image, error) {
+	r := bufio.NewReader(reader)
+	h, err := readNetpbmHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	img := image.NewGray(image.Rect(0, 0, h.width, h.height))
+	switch h.magic {
+	case "P1":
+		for i := range img.Pix {
+			var b byte
+			for b != '0' && b != '1' {
+				if b, err = r.ReadByte(); err != nil {
+					return nil, err
+				}
+			}
+			if b == '0' {
+				img.Pix[i] = 0xFF
+			}
+		}
+	case "P4":
+		row := make([]byte, (h.width+7)/8)
+		for y := 0; y < h.height; y++ {
+			if _, err := io.ReadFull(r, row); err != nil {
+				return nil, err
+			}
+			for x := 0; x < h.width; x++ {
+				if row[x/8]&(0x80>>uint(x%8)) == 0 {
+					img.Pix[y*img.Stride+x] = 0xFF
+				}
+			}
+		}
+	case "P2":
+		for i := range img.Pix {
+			value, err := netpbmInt(r)
+			if err != nil {
+				return nil, err
+			}
+			img.Pix[i] = netpbmGray(value, h.maxValue)
+		}
+	case "P5":
+		size := 1
+		if h.maxValue > 255 {
+			size = 2
+		}
+		sample := make([]byte, size)
+		for i := range img.Pix {
+			if _, err := io.ReadFull(r, sample); err != nil {
+				return nil, err
+			}
+			value := int(sample[0])
+			if size == 2 {
+				value = value<<8 | int(sample[1])
+			}
+			img.Pix[i] = netpbmGray(value, h.maxValue)
+		}
+	}
+	return img, nil
+}
+
+func netpbmGray(value, maxValue int) uint8 {
+	if value > maxValue {
+		value = maxValue
+	}
+	return uint8(value * 0xFF / maxValue)
+}
@@ -5,18 +5,63 @@ import "io"
 // SignalLevels holds the number of supported signal levels
 var SignalLevels int
 
+// SignalLevelWidth holds the width of the signal level icon, in pixels
+var SignalLevelWidth int
+
+// TextStyle tells how a message is printed
+type TextStyle struct {
+	// Font is the name of a registered font, the built-in font is used when empty
+	Font string
+	// Proportional lays the characters out by their own widths instead of fixed width cells
+	// The proportional variant of the built-in font is used when Font is empty
+	Proportional bool
+	// Scale magnifies every pixel of the font into a square of Scale by Scale pixels, 1 to 4
+	// Text taller than 8 pixels extends over the following lines
+	Scale int
+}
+
+// DisplaySettings are the settings of the panel that do not change the screen contents
+type DisplaySettings struct {
+	// Contrast is the brightness of the lit pixels, 0 to 255
+	Contrast int
+	// Inverted lights the blank pixels and blanks the lit ones
+	Inverted bool
+	// On tells whether the panel is lit, a panel that is off keeps its contents and draws almost no power
+	On bool
+}
+
 // Screen contains resources required to work with the OLED screen
+// Drawing operations only change the framebuffer, call Flush to send the changes to the screen
 type Screen interface {
 	// Print displays a string in the specified position of the screen
 	Print(line int, offset int, message string) error
+	// PrintStyled displays a string in the specified position of the screen using the given style
+	PrintStyled(line int, offset int, message string, style TextStyle) error
 	// DisplaySignalLevel displays signal level icon in the specified position of the screen
 	DisplaySignalLevel(line int, offset int, level int) error
 	// DisplayImageFile loads image from the specified file and displays it on the screen
 	DisplayImageFile(filepath string) error
-	// DisplayImage loads image from the provided reader and displays it on the screen
+	// DisplayImage loads image in any supported format from the provided reader and displays it on the screen
 	DisplayImage(reader io.Reader) error
-	// Clear erases screen RAM contents
+	// DisplayImageStyled loads image from the provided reader and displays it on the screen converted to monochrome in the given style
+	DisplayImageStyled(reader io.Reader, style ImageStyle) error
+	// Clear erases screen contents
 	Clear() error
+	// Flush sends the framebuffer pages that changed since the last flush to the screen
+	Flush() error
+	// Framebuffer returns the framebuffer that holds the current screen contents
+	// Use it to draw pixels, lines, rectangles, circles and bitmaps
+	Framebuffer() *Framebuffer
+	// SetContrast changes the brightness of the lit pixels, 0 to 255
+	SetContrast(contrast int) error
+	// SetInverted swaps lit and blank pixels on the panel, the framebuffer is left as is
+	SetInverted(inverted bool) error
+	// SetPower turns the panel on, or off keeping its contents
+	SetPower(on bool) error
+	// DisplaySettings returns the current contrast, inversion and power state of the panel
+	DisplaySettings() DisplaySettings
+	// Description tells which device is used to display the screen contents
+	Description() string
 	// Close releases all the resources allocated by this instance of Screen
 	Close() error
 }
@@ -0,0 +1,43 @@
+package oled
+
+import "fmt"
+
+// Orientation tells how the picture is turned on the panel
+type Orientation struct {
+	// Rotation is the clockwise rotation of the picture in degrees: 0, 90, 180 or 270
+	// At 90 and 270 degrees the screen is 64 pixels wide and 128 pixels high
+	Rotation int
+	// MirrorX flips the picture left to right and MirrorY flips it upside down, before it is rotated
+	MirrorX, MirrorY bool
+}
+
+func (o Orientation) check() error {
+	switch o.Rotation {
+	case 0, 90, 180, 270:
+		return nil
+	}
+	return fmt.Errorf("Rotation should be 0, 90, 180 or 270 degrees")
+}
+
+func (o Orientation) portrait() bool {
+	return o.Rotation == 90 || o.Rotation == 270
+}
+
+// physical maps the position on a screen of the given size to the position on the panel
+func (o Orientation) physical(x, y, width, height int) (int, int) {
+	if o.MirrorX {
+		x = width - 1 - x
+	}
+	if o.MirrorY {
+		y = height - 1 - y
+	}
+	switch o.Rotation {
+	case 90:
+		return height - 1 - y, x
+	case 180:
+		return width - 1 - x, height - 1 - y
+	case 270:
+		return y, width - 1 - x
+	}
+	return x, y
+}
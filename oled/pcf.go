@@ -0,0 +1,312 @@
+package oled
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Table types and format flags of the Portable Compiled Format
+const (
+	pcfProperties       = 1 << 0
+	pcfAccelerators     = 1 << 1
+	pcfMetrics          = 1 << 2
+	pcfBitmaps          = 1 << 3
+	pcfBdfEncodings     = 1 << 5
+	pcfBdfAccelerators  = 1 << 8
+	pcfCompressedFormat = 0x100
+	pcfByteOrderMsb     = 1 << 2
+	pcfBitOrderMsb      = 1 << 3
+)
+
+type pcfMetric struct {
+	left, right, width, ascent, descent int
+}
+
+// pcfTable reads the values of a single table, honoring the byte order of its format
+type pcfTable struct {
+	data   []byte
+	format uint32
+	order  binary.ByteOrder
+	pos    int
+	err    error
+}
+
+func (t *pcfTable) next(size int) []byte {
+	if t.err != nil {
+		return nil
+	}
+	if t.pos+size > len(t.data) {
+		t.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	b := t.data[t.pos : t.pos+size]
+	t.pos += size
+	return b
+}
+
+func (t *pcfTable) uint8() int {
+	if b := t.next(1); b != nil {
+		return int(b[0])
+	}
+	return 0
+}
+
+func (t *pcfTable) int16() int {
+	if b := t.next(2); b != nil {
+		return int(int16(t.order.Uint16(b)))
+	}
+	return 0
+}
+
+func (t *pcfTable) uint16() int {
+	if b := t.next(2); b != nil {
+		return int(t.order.Uint16(b))
+	}
+	return 0
+}
+
+func (t *pcfTable) int32() int {
+	if b := t.next(4); b != nil {
+		return int(int32(t.order.Uint32(b)))
+	}
+	return 0
+}
+
+// ParsePCF reads a font in the Portable Compiled Format, as produced by bdftopcf
+// Glyph encodings are expected to be Unicode code points, which is the case for the ISO10646 and ISO8859-1 charsets
+func ParsePCF(reader io.Reader) (*Font, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 8 || !bytes.Equal(data[:4], []byte("\x01fcp")) {
+		return nil, fmt.Errorf("not a PCF file")
+	}
+	tables := make(map[int]*pcfTable)
+	count := int(binary.LittleEndian.Uint32(data[4:]))
+	for i := 0; i < count; i++ {
+		entry := 8 + i*16
+		if entry+16 > len(data) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		kind := int(binary.LittleEndian.Uint32(data[entry:]))
+		size := int(binary.LittleEndian.Uint32(data[entry+8:]))
+		offset := int(binary.LittleEndian.Uint32(data[entry+12:]))
+		if offset < 0 || size < 4 || offset+size > len(data) {
+			return nil, fmt.Errorf("table 0x%x is out of bounds", kind)
+		}
+		t := &pcfTable{data: data[offset : offset+size], order: binary.LittleEndian}
+		t.format = binary.LittleEndian.Uint32(t.next(4))
+		if t.format&pcfByteOrderMsb != 0 {
+			t.order = binary.BigEndian
+		}
+		tables[kind] = t
+	}
+	for _, kind := range []int{pcfMetrics, pcfBitmaps, pcfBdfEncodings} {
+		if tables[kind] == nil {
+			return nil, fmt.Errorf("table 0x%x is missing", kind)
+		}
+	}
+
+	properties := pcfReadProperties(tables[pcfProperties])
+	if err := checkCharset(properties["CHARSET_REGISTRY"], properties["CHARSET_ENCODING"]); err != nil {
+		return nil, err
+	}
+	metrics := pcfReadMetrics(tables[pcfMetrics])
+	bitmaps := pcfReadBitmaps(tables[pcfBitmaps], metrics)
+	encodings := pcfReadEncodings(tables[pcfBdfEncodings])
+	for _, kind := range []int{pcfMetrics, pcfBitmaps, pcfBdfEncodings} {
+		if err := tables[kind].err; err != nil {
+			return nil, fmt.Errorf("table 0x%x: %v", kind, err)
+		}
+	}
+
+	glyphs := make(map[rune]*Glyph)
+	for ch, index := range encodings {
+		if index < len(bitmaps) && bitmaps[index] != nil {
+			glyphs[ch] = bitmaps[index]
+		}
+	}
+	if len(glyphs) == 0 {
+		return nil, fmt.Errorf("no glyphs found")
+	}
+	accelerators := tables[pcfBdfAccelerators]
+	if accelerators == nil {
+		accelerators = tables[pcfAccelerators]
+	}
+	var ascent, descent int
+	if accelerators != nil {
+		accelerators.next(8)
+		ascent = accelerators.int32()
+		descent = accelerators.int32()
+	}
+	if accelerators == nil || accelerators.err != nil {
+		ascent, descent = 0, 0
+		for _, m := range metrics {
+			if m.ascent > ascent {
+				ascent = m.ascent
+			}
+			if m.descent > descent {
+				descent = m.descent
+			}
+		}
+	}
+	return newFont(properties["FONT"], ascent, descent, glyphs), nil
+}
+
+// pcfReadProperties returns the string properties of the font
+func pcfReadProperties(t *pcfTable) map[string]string {
+	properties := make(map[string]string)
+	if t == nil {
+		return properties
+	}
+	count := t.int32()
+	type property struct {
+		name, value int
+		isString    bool
+	}
+	var props []property
+	for i := 0; i < count && t.err == nil; i++ {
+		p := property{name: t.int32()}
+		p.isString = t.uint8() != 0
+		p.value = t.int32()
+		props = append(props, p)
+	}
+	if count&3 != 0 {
+		t.next(4 - count&3)
+	}
+	size := t.int32()
+	values := t.next(size)
+	if t.err != nil {
+		return properties
+	}
+	str := func(offset int) string {
+		if offset < 0 || offset >= len(values) {
+			return ""
+		}
+		end := bytes.IndexByte(values[offset:], 0)
+		if end < 0 {
+			return string(values[offset:])
+		}
+		return string(values[offset : offset+end])
+	}
+	for _, p := range props {
+		if p.isString {
+			properties[str(p.name)] = str(p.value)
+		}
+	}
+	return properties
+}
+
+func pcfReadMetrics(t *pcfTable) []pcfMetric {
+	var metrics []pcfMetric
+	if t.format&pcfCompressedFormat != 0 {
+		count := t.uint16()
+		for i := 0; i < count && t.err == nil; i++ {
+			metrics = append(metrics, pcfMetric{
+				left:    t.uint8() - 0x80,
+				right:   t.uint8() - 0x80,
+				width:   t.uint8() - 0x80,
+				ascent:  t.uint8() - 0x80,
+				descent: t.uint8() - 0x80,
+			})
+		}
+	} else {
+		count := t.int32()
+		for i := 0; i < count && t.err == nil; i++ {
+			metrics = append(metrics, pcfMetric{
+				left:    t.int16(),
+				right:   t.int16(),
+				width:   t.int16(),
+				ascent:  t.int16(),
+				descent: t.int16(),
+			})
+			t.int16() // attributes
+		}
+	}
+	return metrics
+}
+
+func pcfReadBitmaps(t *pcfTable, metrics []pcfMetric) []*Glyph {
+	count := t.int32()
+	if count != len(metrics) {
+		t.err = fmt.Errorf("%d bitmaps for %d glyphs", count, len(metrics))
+		return nil
+	}
+	offsets := make([]int, count)
+	for i := range offsets {
+		offsets[i] = t.int32()
+	}
+	sizes := []int{t.int32(), t.int32(), t.int32(), t.int32()}
+	padding := t.format & 3
+	data := t.next(sizes[padding])
+	if t.err != nil {
+		return nil
+	}
+	data = append([]byte{}, data...)
+	if t.format&pcfBitOrderMsb == 0 {
+		for i, b := range data {
+			var reversed byte
+			for bit := 0; bit < 8; bit++ {
+				if b&(1<<uint(bit)) != 0 {
+					reversed |= 0x80 >> uint(bit)
+				}
+			}
+			data[i] = reversed
+		}
+	}
+	if (t.format&pcfByteOrderMsb != 0) != (t.format&pcfBitOrderMsb != 0) {
+		unit := 1 << ((t.format >> 4) & 3)
+		for i := 0; i+unit <= len(data); i += unit {
+			for a, b := i, i+unit-1; a < b; a, b = a+1, b-1 {
+				data[a], data[b] = data[b], data[a]
+			}
+		}
+	}
+	rowPadding := 1 << padding
+	glyphs := make([]*Glyph, count)
+	for i, m := range metrics {
+		g := &Glyph{
+			Width:   m.right - m.left,
+			Height:  m.ascent + m.descent,
+			XOffset: m.left,
+			YOffset: -m.descent,
+			Advance: m.width,
+		}
+		if g.Width < 0 || g.Height < 0 {
+			continue
+		}
+		g.bits = make([]bool, g.Width*g.Height)
+		rowSize := (g.Width + 7) / 8
+		rowSize = (rowSize + rowPadding - 1) / rowPadding * rowPadding
+		if offsets[i] < 0 || offsets[i]+rowSize*g.Height > len(data) {
+			continue
+		}
+		for y := 0; y < g.Height; y++ {
+			row := data[offsets[i]+y*rowSize:]
+			for x := 0; x < g.Width; x++ {
+				g.bits[y*g.Width+x] = row[x/8]&(0x80>>uint(x%8)) != 0
+			}
+		}
+		glyphs[i] = g
+	}
+	return glyphs
+}
+
+func pcfReadEncodings(t *pcfTable) map[rune]int {
+	minByte2, maxByte2 := t.int16(), t.int16()
+	minByte1, maxByte1 := t.int16(), t.int16()
+	t.int16() // default character
+	encodings := make(map[rune]int)
+	for byte1 := minByte1; byte1 <= maxByte1 && t.err == nil; byte1++ {
+		for byte2 := minByte2; byte2 <= maxByte2 && t.err == nil; byte2++ {
+			index := t.uint16()
+			if index != 0xFFFF && t.err == nil {
+				encodings[rune(byte1<<8|byte2)] = index
+			}
+		}
+	}
+	return encodings
+}
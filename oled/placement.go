@@ -0,0 +1,131 @@
+package oled
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+)
+
+// Placement tells how an image of any size is scaled and placed on the screen
+type Placement int
+
+// Supported image placements
+const (
+	// PlaceFit scales the image to fit the screen and keeps the aspect ratio, leaving blank bars on two sides
+	PlaceFit Placement = iota
+	// PlaceFill scales the image to cover the whole screen and keeps the aspect ratio, cropping the parts that stick out
+	PlaceFill
+	// PlaceStretch scales the image to the size of the screen, ignoring the aspect ratio
+	PlaceStretch
+	// PlaceCenter puts the image in the middle of the screen without scaling
+	PlaceCenter
+)
+
+var placementNames = map[Placement]string{
+	PlaceFit:     "fit",
+	PlaceFill:    "fill",
+	PlaceStretch: "stretch",
+	PlaceCenter:  "center",
+}
+
+func (p Placement) String() string {
+	if name, found := placementNames[p]; found {
+		return name
+	}
+	return fmt.Sprintf("Placement(%d)", int(p))
+}
+
+// PlacementByName returns an image placement given its case insensitive name, e.g. fill
+func PlacementByName(name string) (Placement, error) {
+	for p, n := range placementNames {
+		if strings.EqualFold(n, name) {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("Unsupported placement %q", name)
+}
+
+// RenderImage converts the image to a bitmap of the given screen size, placed and dithered as the style says
+// The parts of the screen the image does not cover are blank
+func RenderImage(img image.Image, style ImageStyle, width, height int) (*Bitmap, error) {
+	if err := style.check(); err != nil {
+		return nil, err
+	}
+	img, at, err := placed(img, style, width, height)
+	if err != nil {
+		return nil, err
+	}
+	b, err := Dithered(img, style)
+	if err != nil {
+		return nil, err
+	}
+	screen := NewBitmap(width, height)
+	for y := 0; y < b.Height; y++ {
+		for x := 0; x < b.Width; x++ {
+			screen.SetPixel(at.X+x, at.Y+y, b.bits[y*b.Width+x])
+		}
+	}
+	return screen, nil
+}
+
+// placed scales the image as the style says and returns it along with the position of its top left corner
+// on a screen of the given size, images without pixels cannot be placed
+func placed(img image.Image, style ImageStyle, screenWidth, screenHeight int) (image.Image, image.Point, error) {
+	rect := img.Bounds()
+	width, height := rect.Dx(), rect.Dy()
+	if width < 1 || height < 1 {
+		return nil, image.Point{}, fmt.Errorf("Image is empty")
+	}
+	switch style.Placement {
+	case PlaceFit, PlaceFill:
+		// compare the aspect ratios to find the side that touches the edges of the screen
+		wide := width*screenHeight > height*screenWidth
+		if wide == (style.Placement == PlaceFit) {
+			width, height = screenWidth, (height*screenWidth+width/2)/width
+		} else {
+			width, height = (width*screenHeight+height/2)/height, screenHeight
+		}
+	case PlaceStretch:
+		width, height = screenWidth, screenHeight
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+	if width != rect.Dx() || height != rect.Dy() {
+		img = scaled(img, width, height)
+	}
+	return img, image.Pt((screenWidth-width)/2+style.X, (screenHeight-height)/2+style.Y), nil
+}
+
+// scaled resizes the image to a gray image of the given size
+// Every pixel gets the average gray level of the part of the original image it covers
+func scaled(img image.Image, width, height int) *image.Gray16 {
+	rect := img.Bounds()
+	result := image.NewGray16(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		top := rect.Min.Y + y*rect.Dy()/height
+		bottom := rect.Min.Y + (y+1)*rect.Dy()/height
+		if bottom <= top {
+			bottom = top + 1
+		}
+		for x := 0; x < width; x++ {
+			left := rect.Min.X + x*rect.Dx()/width
+			right := rect.Min.X + (x+1)*rect.Dx()/width
+			if right <= left {
+				right = left + 1
+			}
+			sum := 0
+			for yy := top; yy < bottom; yy++ {
+				for xx := left; xx < right; xx++ {
+					sum += int(grayOnWhite(img.At(xx, yy)))
+				}
+			}
+			result.SetGray16(x, y, color.Gray16{uint16(sum / ((bottom - top) * (right - left)))})
+		}
+	}
+	return result
+}
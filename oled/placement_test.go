@@ -0,0 +1,80 @@
+package oled
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestPlaced(t *testing.T) {
+	wide := image.NewGray(image.Rect(0, 0, 256, 64))
+	tall := image.NewGray(image.Rect(0, 0, 32, 64))
+	tests := []struct {
+		img       image.Image
+		placement Placement
+		size, at  image.Point
+	}{
+		{wide, PlaceFit, image.Pt(128, 32), image.Pt(0, 16)},
+		{wide, PlaceFill, image.Pt(256, 64), image.Pt(-64, 0)},
+		{wide, PlaceStretch, image.Pt(128, 64), image.Pt(0, 0)},
+		{wide, PlaceCenter, image.Pt(256, 64), image.Pt(-64, 0)},
+		{tall, PlaceFit, image.Pt(32, 64), image.Pt(48, 0)},
+		{tall, PlaceFill, image.Pt(128, 256), image.Pt(0, -96)},
+		{tall, PlaceStretch, image.Pt(128, 64), image.Pt(0, 0)},
+		{tall, PlaceCenter, image.Pt(32, 64), image.Pt(48, 0)},
+	}
+	for _, test := range tests {
+		img, at, err := placed(test.img, ImageStyle{Placement: test.placement}, Width, Height)
+		if err != nil {
+			t.Fatalf("Failed to place %v: %v", test.img.Bounds().Size(), err)
+		}
+		if size := img.Bounds().Size(); size != test.size || at != test.at {
+			t.Errorf("%v of %v: got %v at %v, expected %v at %v", test.placement, test.img.Bounds().Size(), size, at, test.size, test.at)
+		}
+	}
+	_, at, _ := placed(tall, ImageStyle{Placement: PlaceCenter, X: -48, Y: 10}, Width, Height)
+	if at != image.Pt(0, 10) {
+		t.Errorf("Offset image is placed at %v", at)
+	}
+}
+
+func TestEmptyImageIsNotPlaced(t *testing.T) {
+	for _, rect := range []image.Rectangle{image.Rect(0, 0, 0, 5), image.Rect(0, 0, 5, 0)} {
+		for placement := range placementNames {
+			if _, err := RenderImage(image.NewGray(rect), ImageStyle{Placement: placement}, Width, Height); err == nil {
+				t.Errorf("Expected an error for %v of a %v image", placement, rect.Size())
+			}
+		}
+	}
+}
+
+func TestScaledAveragesGray(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 4, 2))
+	img.SetGray(0, 0, color.Gray{0xFF})
+	img.SetGray(1, 1, color.Gray{0xFF})
+	small := scaled(img, 2, 1)
+	if small.Gray16At(0, 0).Y != 0x7FFF || small.Gray16At(1, 0).Y != 0 {
+		t.Errorf("Unexpected scaled pixels %v", small.Pix)
+	}
+}
+
+func TestDisplayImageFit(t *testing.T) {
+	scr, tr := openRecording(t, SH1106)
+	scr.Framebuffer().FillRect(0, 0, Width, Height, true)
+	scr.Flush()
+	tr.Reset()
+	img := image.NewGray(image.Rect(0, 0, 16, 16))
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	if err := scr.DisplayImageStyled(&buf, ImageStyle{Placement: PlaceFit, X: 8}); err != nil {
+		t.Fatalf("Failed to display image: %v", err)
+	}
+	fb := scr.Framebuffer()
+	if !fb.Pixel(40, 0) || !fb.Pixel(40+63, 63) || fb.Pixel(39, 0) || fb.Pixel(40+64, 63) {
+		t.Errorf("Black square is not fit to the screen height and shifted by 8 pixels")
+	}
+	scr.Flush()
+	assertGolden(t, "image-fit", tr.String())
+}
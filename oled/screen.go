@@ -0,0 +1,210 @@
+package oled
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// controllerScreen draws into a framebuffer and sends it to a controller chip over a transport
+type controllerScreen struct {
+	t          Transport
+	ctl        Controller
+	connection string
+	fb         Framebuffer
+	settings   DisplaySettings
+}
+
+// newControllerScreen initializes the controller and returns a blank screen in the given orientation
+// The transport is closed when initialization fails
+func newControllerScreen(t Transport, ctl Controller, o Orientation, connection string) (Screen, error) {
+	if ctl == nil {
+		ctl = SH1106
+	}
+	screen := &controllerScreen{t: t, ctl: ctl, connection: connection}
+	if err := screen.fb.SetOrientation(o); err != nil {
+		t.Close()
+		return nil, err
+	}
+	if err := screen.init(); err != nil {
+		t.Close()
+		return nil, err
+	}
+	return screen, nil
+}
+
+func (s *controllerScreen) init() error {
+	if err := s.t.Command(s.ctl.initSequence()...); err != nil {
+		return fmt.Errorf("Failed to initialize %s: %v", s.ctl.Name(), err)
+	}
+	if err := s.clearRAM(); err != nil {
+		return fmt.Errorf("Failed to clean: %v", err)
+	}
+	if err := s.t.Command(0xAF); err != nil {
+		return fmt.Errorf("Failed to turn on: %v", err)
+	}
+	s.settings = DisplaySettings{Contrast: s.ctl.defaultContrast(), On: true}
+	return nil
+}
+
+func (s *controllerScreen) SetContrast(contrast int) error {
+	if contrast < 0 || contrast > 255 {
+		return fmt.Errorf("Contrast should be between 0 and 255")
+	}
+	if err := s.t.Command(0x81, byte(contrast)); err != nil {
+		return err
+	}
+	s.settings.Contrast = contrast
+	return nil
+}
+
+func (s *controllerScreen) SetInverted(inverted bool) error {
+	command := byte(0xA6)
+	if inverted {
+		command = 0xA7
+	}
+	if err := s.t.Command(command); err != nil {
+		return err
+	}
+	s.settings.Inverted = inverted
+	return nil
+}
+
+func (s *controllerScreen) SetPower(on bool) error {
+	command := byte(0xAE)
+	if on {
+		command = 0xAF
+	}
+	if err := s.t.Command(command); err != nil {
+		return err
+	}
+	s.settings.On = on
+	return nil
+}
+
+func (s *controllerScreen) DisplaySettings() DisplaySettings {
+	return s.settings
+}
+
+func (s *controllerScreen) Description() string {
+	return fmt.Sprintf("%s on %s", s.ctl.Name(), s.connection)
+}
+
+func (s *controllerScreen) Close() error {
+	return s.t.Close()
+}
+
+func (s *controllerScreen) Clear() error {
+	s.fb.Clear()
+	return nil
+}
+
+// clearRAM blanks the whole controller RAM, including the columns that are not visible
+func (s *controllerScreen) clearRAM() error {
+	emptyLine := make([]byte, s.ctl.ramWidth())
+	for i := 0; i < Pages; i++ {
+		if err := s.write(i, 0, emptyLine); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// write puts the data into the given page of the controller RAM starting at the given RAM column,
+// in a single transfer when the transport allows
+func (s *controllerScreen) write(page, column int, data []byte) error {
+	cmds := s.ctl.setAddress(page, column)
+	if t, ok := s.t.(commandDataTransport); ok {
+		if err := t.CommandData(cmds, data); err != nil {
+			return fmt.Errorf("Failed to output page %d: %v", page, err)
+		}
+		return nil
+	}
+	if err := s.t.Command(cmds...); err != nil {
+		return fmt.Errorf("Failed to set page: %v", err)
+	}
+	if err := s.t.Data(data); err != nil {
+		return fmt.Errorf("Failed to output page %d: %v", page, err)
+	}
+	return nil
+}
+
+func (s *controllerScreen) Flush() error {
+	return s.fb.flush(func(page, x int, data []byte) error {
+		return s.write(page, s.ctl.columnOffset()+x, data)
+	})
+}
+
+func (s *controllerScreen) Framebuffer() *Framebuffer {
+	return &s.fb
+}
+
+func (s *controllerScreen) Print(line int, offset int, message string) error {
+	return s.PrintStyled(line, offset, message, TextStyle{})
+}
+
+func (s *controllerScreen) PrintStyled(line int, offset int, message string, style TextStyle) error {
+	return s.fb.printStyled(line, offset, message, style)
+}
+
+func (s *controllerScreen) DisplaySignalLevel(line int, offset int, level int) error {
+	s.fb.signalLevel(line, offset, level)
+	return nil
+}
+
+func (s *controllerScreen) DisplayImageFile(filepath string) error {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return s.DisplayImage(file)
+}
+
+func (s *controllerScreen) DisplayImage(reader io.Reader) error {
+	return s.DisplayImageStyled(reader, ImageStyle{})
+}
+
+func (s *controllerScreen) DisplayImageStyled(reader io.Reader, style ImageStyle) error {
+	return s.fb.displayImage(reader, style)
+}
+
+// printStyled draws the text at the given line and pixel offset, the way every screen prints
+func (fb *Framebuffer) printStyled(line int, offset int, message string, style TextStyle) error {
+	_, err := fb.DrawText(offset, fb.lineTop(line), message, style)
+	return err
+}
+
+// signalLevel draws the signal level icon at the given line and pixel offset
+func (fb *Framebuffer) signalLevel(line int, offset int, level int) {
+	if level >= len(signalLevels) {
+		level = len(signalLevels) - 1
+	}
+	if level < 0 {
+		level = 0
+	}
+	top := fb.lineTop(line)
+	for i, bits := range signalLevels[level] {
+		for y := 0; y < 8; y++ {
+			fb.SetPixel(offset+i, top+y, bits&(1<<uint(y)) != 0)
+		}
+	}
+}
+
+// displayImage decodes the image and draws it over the whole screen in the given style
+func (fb *Framebuffer) displayImage(reader io.Reader, style ImageStyle) error {
+	if err := style.check(); err != nil {
+		return err
+	}
+	img, err := DecodeImage(reader, style.Format)
+	if err != nil {
+		return err
+	}
+	width, height := fb.Size()
+	b, err := RenderImage(img, style, width, height)
+	if err != nil {
+		return err
+	}
+	fb.DrawBitmap(0, 0, b)
+	return nil
+}
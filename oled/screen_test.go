@@ -0,0 +1,330 @@
+package oled
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func assertGolden(t *testing.T, name string, actual string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, []byte(actual), 0644); err != nil {
+			t.Fatalf("Failed to update %s: %v", path, err)
+		}
+		return
+	}
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	if string(expected) != actual {
+		t.Errorf("Byte stream does not match %s\nExpected:\n%s\nActual:\n%s", path, expected, actual)
+	}
+}
+
+func openRecording(t *testing.T, ctl Controller) (Screen, *RecordingTransport) {
+	t.Helper()
+	tr := &RecordingTransport{}
+	scr, err := Open(&TransportOpener{Transport: tr, Controller: ctl})
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	return scr, tr
+}
+
+func TestInitSequence(t *testing.T) {
+	for _, ctl := range []Controller{SH1106, SSD1306} {
+		_, tr := openRecording(t, ctl)
+		assertGolden(t, "init-"+ctl.Name(), tr.String())
+	}
+}
+
+func TestPrint(t *testing.T) {
+	scr, tr := openRecording(t, SH1106)
+	tr.Reset()
+	scr.Print(1, 3, "Hello, world!")
+	scr.Flush()
+	assertGolden(t, "print", tr.String())
+}
+
+func TestPrintRotated(t *testing.T) {
+	for _, rotation := range []int{90, 180} {
+		tr := &RecordingTransport{}
+		scr, err := Open(&TransportOpener{Transport: tr, Controller: SH1106, Orientation: Orientation{Rotation: rotation}})
+		if err != nil {
+			t.Fatalf("Failed to open: %v", err)
+		}
+		tr.Reset()
+		scr.Print(1, 3, "Hello!")
+		scr.Flush()
+		assertGolden(t, fmt.Sprintf("print-%d", rotation), tr.String())
+	}
+}
+
+func TestPrintUnchangedLine(t *testing.T) {
+	scr, tr := openRecording(t, SH1106)
+	scr.Print(1, 3, "Hello, world!")
+	scr.Flush()
+	tr.Reset()
+	scr.Print(1, 3, "Hello, world!")
+	scr.Flush()
+	if len(tr.Transfers) != 0 {
+		t.Errorf("Unchanged line was sent again:\n%s", tr)
+	}
+}
+
+func TestClear(t *testing.T) {
+	scr, tr := openRecording(t, SSD1306)
+	scr.Print(0, 0, "Line 0")
+	scr.Print(7, 0, "Line 7")
+	scr.Flush()
+	tr.Reset()
+	scr.Clear()
+	scr.Flush()
+	assertGolden(t, "clear", tr.String())
+}
+
+func TestDisplaySignalLevel(t *testing.T) {
+	scr, tr := openRecording(t, SH1106)
+	tr.Reset()
+	for level := 0; level < SignalLevels; level++ {
+		scr.DisplaySignalLevel(level, 100, level)
+	}
+	scr.Flush()
+	assertGolden(t, "signal-level", tr.String())
+}
+
+func TestDisplayImage(t *testing.T) {
+	scr, tr := openRecording(t, SH1106)
+	tr.Reset()
+	img := image.NewGray(image.Rect(0, 0, Width, Height))
+	for y := 0; y < Height; y++ {
+		for x := 0; x < Width; x++ {
+			if (x/8+y/8)%2 == 0 {
+				img.SetGray(x, y, color.Gray{0xFF})
+			}
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	if err := scr.DisplayImage(&buf); err != nil {
+		t.Fatalf("Failed to display image: %v", err)
+	}
+	scr.Flush()
+	assertGolden(t, "image", tr.String())
+}
+
+func TestClosedTransport(t *testing.T) {
+	scr, _ := openRecording(t, SH1106)
+	scr.Close()
+	scr.Print(0, 0, "Closed")
+	if err := scr.Flush(); err == nil {
+		t.Errorf("Flush succeeded on a closed transport")
+	}
+}
+
+func TestDisplayImageDithered(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, Width, Height))
+	for y := 0; y < Height; y++ {
+		for x := 0; x < Width; x++ {
+			img.SetGray(x, y, color.Gray{uint8(x * 2)})
+		}
+	}
+	for _, dither := range []Dither{DitherFloydSteinberg, DitherAtkinson, DitherBayer} {
+		t.Run(dither.String(), func(t *testing.T) {
+			scr, tr := openRecording(t, SH1106)
+			tr.Reset()
+			var buf bytes.Buffer
+			png.Encode(&buf, img)
+			if err := scr.DisplayImageStyled(&buf, ImageStyle{Dither: dither}); err != nil {
+				t.Fatalf("Failed to display image: %v", err)
+			}
+			scr.Flush()
+			assertGolden(t, "image-"+dither.String(), tr.String())
+		})
+	}
+}
+
+func TestDisplaySettings(t *testing.T) {
+	scr, tr := openRecording(t, SSD1306)
+	if settings := scr.DisplaySettings(); settings != (DisplaySettings{Contrast: 0xCF, On: true}) {
+		t.Errorf("Unexpected initial settings %+v", settings)
+	}
+	tr.Reset()
+	scr.SetContrast(0x10)
+	scr.SetInverted(true)
+	scr.SetPower(false)
+	scr.SetInverted(false)
+	scr.SetPower(true)
+	if err := scr.SetContrast(256); err == nil {
+		t.Errorf("Expected an error for contrast 256")
+	}
+	if tr.String() != "C 81 10\nC a7\nC ae\nC a6\nC af\n" {
+		t.Errorf("Unexpected commands:\n%s", tr.String())
+	}
+	if settings := scr.DisplaySettings(); settings != (DisplaySettings{Contrast: 0x10, On: true}) {
+		t.Errorf("Unexpected settings %+v", settings)
+	}
+}
+
+func TestMaxTransfer(t *testing.T) {
+	tr := &RecordingTransport{}
+	scr, err := Open(&TransportOpener{Transport: tr, Controller: SH1106, MaxTransfer: 32})
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	tr.Reset()
+	scr.Framebuffer().FillRect(0, 0, Width, 8, true)
+	scr.Flush()
+	var sizes []int
+	for _, transfer := range tr.Transfers {
+		if !transfer.Command {
+			sizes = append(sizes, len(transfer.Bytes))
+		}
+	}
+	if fmt.Sprint(sizes) != "[32 32 32 32]" {
+		t.Errorf("Unexpected RAM writes of %v bytes", sizes)
+	}
+}
+
+// benchmarkFlush measures drawing with draw and flushing it, and reports the transfers and the bytes sent for every flush
+func benchmarkFlush(b *testing.B, maxTransfer int, draw func(scr Screen, i int)) {
+	tr := &RecordingTransport{}
+	scr, err := Open(&TransportOpener{Transport: tr, Controller: SH1106, MaxTransfer: maxTransfer})
+	if err != nil {
+		b.Fatalf("Failed to open: %v", err)
+	}
+	benchmarkTransfers(b, func(i int) {
+		tr.Reset()
+		draw(scr, i)
+		scr.Flush()
+	}, func() [][]byte {
+		return recordedBytes(tr)
+	})
+}
+
+// benchmarkI2cFlush is benchmarkFlush over I2C, where the address of a page goes in the same write as its data
+func benchmarkI2cFlush(b *testing.B, maxTransfer int, draw func(scr Screen, i int)) {
+	dev := &recordingI2cDevice{}
+	scr, err := newControllerScreen(&i2cTransport{dev, maxTransfer}, SH1106, Orientation{}, "benchmark")
+	if err != nil {
+		b.Fatalf("Failed to open: %v", err)
+	}
+	benchmarkTransfers(b, func(i int) {
+		dev.writes = nil
+		draw(scr, i)
+		scr.Flush()
+	}, func() [][]byte {
+		return dev.writes
+	})
+}
+
+// recordedBytes returns the bytes of every transfer recorded by the transport
+func recordedBytes(tr *RecordingTransport) [][]byte {
+	var transfers [][]byte
+	for _, transfer := range tr.Transfers {
+		transfers = append(transfers, transfer.Bytes)
+	}
+	return transfers
+}
+
+// benchmarkTransfers runs op and reports the number of transfers and the bytes they took on average, as told by writes
+func benchmarkTransfers(b *testing.B, op func(i int), writes func() [][]byte) {
+	transfers, sent := 0, 0
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		op(i)
+		for _, transfer := range writes() {
+			transfers++
+			sent += len(transfer)
+		}
+	}
+	b.ReportMetric(float64(transfers)/float64(b.N), "transfers/op")
+	b.ReportMetric(float64(sent)/float64(b.N), "sent-B/op")
+}
+
+// alternating returns one of two texts of the same length, so that every flush has something to send
+func alternating(i int, texts ...string) string {
+	return texts[i%len(texts)]
+}
+
+func BenchmarkPrintLine(b *testing.B) {
+	benchmarkFlush(b, 0, func(scr Screen, i int) {
+		scr.Print(3, 0, alternating(i, "QUICK BROWN FOX JUMPS", "over the lazy dog {|}"))
+	})
+}
+
+// BenchmarkPrintLinePerCharacter replays the writes of the driver that sent every character on its own,
+// the address of the line followed by the columns of a character and a blank column, to compare with BenchmarkPrintLine
+func BenchmarkPrintLinePerCharacter(b *testing.B) {
+	tr := &RecordingTransport{}
+	benchmarkTransfers(b, func(i int) {
+		tr.Reset()
+		tr.Command(SH1106.setAddress(3, SH1106.columnOffset())...)
+		for _, ch := range alternating(i, "QUICK BROWN FOX JUMPS", "OVER THE LAZY DOG {|}") {
+			tr.Data(append(append([]byte{}, font[ch-' ']...), 0x00))
+		}
+	}, func() [][]byte {
+		return recordedBytes(tr)
+	})
+}
+
+func BenchmarkPrintLineI2c(b *testing.B) {
+	benchmarkI2cFlush(b, 0, func(scr Screen, i int) {
+		scr.Print(3, 0, alternating(i, "QUICK BROWN FOX JUMPS", "over the lazy dog {|}"))
+	})
+}
+
+func BenchmarkPrintLineI2c32ByteTransfers(b *testing.B) {
+	benchmarkI2cFlush(b, 32, func(scr Screen, i int) {
+		scr.Print(3, 0, alternating(i, "QUICK BROWN FOX JUMPS", "over the lazy dog {|}"))
+	})
+}
+
+func BenchmarkPrintCharacter(b *testing.B) {
+	benchmarkFlush(b, 0, func(scr Screen, i int) {
+		scr.Print(3, 60, alternating(i, "0", "1"))
+	})
+}
+
+func BenchmarkPrintLine32ByteTransfers(b *testing.B) {
+	benchmarkFlush(b, 32, func(scr Screen, i int) {
+		scr.Print(3, 0, alternating(i, "QUICK BROWN FOX JUMPS", "over the lazy dog {|}"))
+	})
+}
+
+func BenchmarkFillAndClear(b *testing.B) {
+	benchmarkFlush(b, 0, func(scr Screen, i int) {
+		if i%2 == 0 {
+			scr.Framebuffer().FillRect(0, 0, Width, Height, true)
+		} else {
+			scr.Clear()
+		}
+	})
+}
+
+func BenchmarkDisplayImage(b *testing.B) {
+	img := image.NewGray(image.Rect(0, 0, Width, Height))
+	for y := 0; y < Height; y++ {
+		for x := 0; x < Width; x++ {
+			img.SetGray(x, y, color.Gray{uint8(x * 2)})
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	encoded := buf.Bytes()
+	benchmarkFlush(b, 0, func(scr Screen, i int) {
+		scr.Clear()
+		scr.DisplayImageStyled(bytes.NewReader(encoded), ImageStyle{Dither: DitherBayer})
+	})
+}
@@ -0,0 +1,146 @@
+package oled
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/exp/io/spi"
+)
+
+// GPIO lines the DC and RST pins of an SPI screen are usually wired to
+const (
+	DefaultDcLine    = 24
+	DefaultResetLine = 25
+)
+
+// SpiOpener allows to open a real screen wired for 4-wire SPI
+// Besides the SPI bus, the screen needs a DC line to tell commands from data and optionally a RST line
+// Use NewSpiOpener to get the usual wiring, GPIO lines are used as given, including line 0
+type SpiOpener struct {
+	// Controller is the controller chip of the screen, SH1106 is used when not set
+	Controller Controller
+	// Device is the path of the spidev device, /dev/spidev0.0 when not set
+	Device string
+	// Speed is the SPI clock speed in Hz, 8 MHz when not set
+	Speed int
+	// GpioChip is the path of the GPIO character device, /dev/gpiochip0 when not set
+	GpioChip string
+	// DcLine is the GPIO line connected to DC
+	DcLine int
+	// ResetLine is the GPIO line connected to RST, other than DcLine, negative when RST is not wired
+	ResetLine int
+	// Orientation tells how the picture is turned on the panel
+	Orientation Orientation
+}
+
+// spiBus is a connection to a single device on an SPI bus
+type spiBus interface {
+	Tx(w, r []byte) error
+	Close() error
+}
+
+// gpioLine is a GPIO line requested as an output
+type gpioLine interface {
+	set(high bool) error
+	Close() error
+}
+
+// NewSpiOpener returns an opener for a screen with DC wired to DefaultDcLine and RST to DefaultResetLine
+func NewSpiOpener() *SpiOpener {
+	return &SpiOpener{DcLine: DefaultDcLine, ResetLine: DefaultResetLine}
+}
+
+func (o *SpiOpener) open() (Screen, error) {
+	if o.ResetLine >= 0 && o.ResetLine == o.DcLine {
+		return nil, fmt.Errorf("DC and RST should be on different GPIO lines, both are on line %d", o.DcLine)
+	}
+	device := o.Device
+	if device == "" {
+		device = "/dev/spidev0.0"
+	}
+	speed := o.Speed
+	if speed == 0 {
+		speed = 8000000
+	}
+	chip := o.GpioChip
+	if chip == "" {
+		chip = "/dev/gpiochip0"
+	}
+
+	bus, err := spi.Open(&spi.Devfs{Dev: device, Mode: spi.Mode0, MaxSpeed: int64(speed)})
+	if err != nil {
+		return nil, err
+	}
+	dc, err := openGpioLine(chip, o.DcLine, "oled-dc", false)
+	if err != nil {
+		bus.Close()
+		return nil, err
+	}
+	var rst gpioLine
+	if o.ResetLine >= 0 {
+		if rst, err = openGpioLine(chip, o.ResetLine, "oled-reset", true); err != nil {
+			dc.Close()
+			bus.Close()
+			return nil, err
+		}
+	}
+	t, err := newSpiTransport(bus, dc, rst)
+	if err != nil {
+		return nil, err
+	}
+	return newControllerScreen(t, o.Controller, o.Orientation, fmt.Sprintf("%s (DC %d, RST %d)", device, o.DcLine, o.ResetLine))
+}
+
+// spiTransport drives the DC line low for commands and high for data
+type spiTransport struct {
+	bus spiBus
+	dc  gpioLine
+	rst gpioLine
+}
+
+// newSpiTransport resets the controller when RST is wired
+// All the devices are closed when the reset fails
+func newSpiTransport(bus spiBus, dc, rst gpioLine) (*spiTransport, error) {
+	t := &spiTransport{bus, dc, rst}
+	if rst != nil {
+		if err := t.reset(); err != nil {
+			t.Close()
+			return nil, fmt.Errorf("Failed to reset: %v", err)
+		}
+	}
+	return t, nil
+}
+
+func (t *spiTransport) reset() error {
+	if err := t.rst.set(false); err != nil {
+		return err
+	}
+	time.Sleep(10 * time.Millisecond)
+	if err := t.rst.set(true); err != nil {
+		return err
+	}
+	time.Sleep(10 * time.Millisecond)
+	return nil
+}
+
+func (t *spiTransport) Command(cmds ...byte) error {
+	if err := t.dc.set(false); err != nil {
+		return err
+	}
+	return t.bus.Tx(cmds, nil)
+}
+
+func (t *spiTransport) Data(payload []byte) error {
+	if err := t.dc.set(true); err != nil {
+		return err
+	}
+	return t.bus.Tx(payload, nil)
+}
+
+func (t *spiTransport) Close() error {
+	if t.rst != nil {
+		t.rst.Close()
+	}
+	t.dc.Close()
+	return t.bus.Close()
+}
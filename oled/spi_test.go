@@ -0,0 +1,127 @@
+package oled
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// fakeSpiWiring records the SPI transfers along with the level of the DC line
+type fakeSpiWiring struct {
+	dc       bool
+	commands []byte
+	data     []byte
+	resets   int
+	closed   int
+}
+
+type fakeSpiBus struct{ w *fakeSpiWiring }
+
+func (b fakeSpiBus) Tx(w, r []byte) error {
+	if b.w.dc {
+		b.w.data = append(b.w.data, w...)
+	} else {
+		b.w.commands = append(b.w.commands, w...)
+	}
+	return nil
+}
+
+func (b fakeSpiBus) Close() error {
+	b.w.closed++
+	return nil
+}
+
+type fakeDcLine struct{ w *fakeSpiWiring }
+
+func (l fakeDcLine) set(high bool) error {
+	l.w.dc = high
+	return nil
+}
+
+func (l fakeDcLine) Close() error {
+	l.w.closed++
+	return nil
+}
+
+type fakeResetLine struct {
+	w    *fakeSpiWiring
+	fail bool
+}
+
+func (l fakeResetLine) set(high bool) error {
+	if l.fail {
+		return fmt.Errorf("line busy")
+	}
+	if !high {
+		l.w.resets++
+	}
+	return nil
+}
+
+func (l fakeResetLine) Close() error {
+	l.w.closed++
+	return nil
+}
+
+func TestSpiTransportInitializesScreen(t *testing.T) {
+	w := &fakeSpiWiring{}
+	tr, err := newSpiTransport(fakeSpiBus{w}, fakeDcLine{w}, fakeResetLine{w: w})
+	if err != nil {
+		t.Fatalf("Failed to create transport: %v", err)
+	}
+	if w.resets != 1 {
+		t.Errorf("Expected a single reset, got %d", w.resets)
+	}
+	scr, err := newControllerScreen(tr, SSD1306, Orientation{}, "fake SPI")
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	init := SSD1306.initSequence()
+	if string(w.commands[:len(init)]) != string(init) {
+		t.Errorf("Unexpected init sequence % x", w.commands[:len(init)])
+	}
+	if w.commands[len(w.commands)-1] != 0xAF {
+		t.Errorf("Screen is not turned on")
+	}
+	if len(w.data) != Pages*SSD1306.ramWidth() {
+		t.Errorf("Expected the whole RAM to be cleared, got %d bytes", len(w.data))
+	}
+
+	w.data = nil
+	scr.Print(0, 0, "!")
+	if err := scr.Flush(); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+	if len(w.data) != 1 || w.data[0] != 0xBE {
+		t.Errorf("Unexpected page data % x", w.data)
+	}
+	scr.Close()
+	if w.closed != 3 {
+		t.Errorf("Expected all the devices to be closed, got %d", w.closed)
+	}
+}
+
+func TestSpiTransportClosesDevicesWhenResetFails(t *testing.T) {
+	w := &fakeSpiWiring{}
+	if _, err := newSpiTransport(fakeSpiBus{w}, fakeDcLine{w}, fakeResetLine{w: w, fail: true}); err == nil {
+		t.Fatalf("Expected reset to fail")
+	}
+	if w.closed != 3 {
+		t.Errorf("Expected all the devices to be closed, got %d", w.closed)
+	}
+}
+
+func TestNewSpiOpenerUsesUsualWiring(t *testing.T) {
+	o := NewSpiOpener()
+	if o.DcLine != DefaultDcLine || o.ResetLine != DefaultResetLine {
+		t.Errorf("Unexpected wiring DC %d, RST %d", o.DcLine, o.ResetLine)
+	}
+}
+
+func TestSpiOpenerRejectsDcAndResetOnSameLine(t *testing.T) {
+	o := &SpiOpener{Device: "/dev/no-such-spidev", DcLine: 24, ResetLine: 24}
+	_, err := o.open()
+	if err == nil || !strings.Contains(err.Error(), "different GPIO lines") {
+		t.Errorf("Expected an error for DC and RST on the same line, got %v", err)
+	}
+}
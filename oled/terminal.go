@@ -0,0 +1,90 @@
+package oled
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"io"
+	"os"
+)
+
+// TerminalOpener allows to open a virtual screen that is drawn in a terminal, for development without the hardware
+// The screen is redrawn on every flush that changes it
+type TerminalOpener struct {
+	// Output is the terminal to draw in, os.Stdout when not set
+	Output io.Writer
+	// Braille draws 2x4 pixels per character with braille patterns, which takes a quarter of the space of half blocks
+	Braille bool
+	// Orientation tells how the picture is turned, see I2cOpener
+	Orientation Orientation
+}
+
+// halfBlocks maps the top and the bottom pixel of a character cell to the character drawing them
+var halfBlocks = [4]rune{' ', '▀', '▄', '█'}
+
+// brailleDots holds the bits of the braille pattern for every pixel of a 2x4 cell
+var brailleDots = [4][2]rune{{0x01, 0x08}, {0x02, 0x10}, {0x04, 0x20}, {0x40, 0x80}}
+
+func (o *TerminalOpener) open() (Screen, error) {
+	output := o.Output
+	if output == nil {
+		output = os.Stdout
+	}
+	cellWidth, cellHeight, cell := 1, 2, halfBlockCell
+	if o.Braille {
+		cellWidth, cellHeight, cell = 2, 4, brailleCell
+	}
+	// clear the terminal once, every picture is then drawn over the previous one
+	fmt.Fprint(output, "\x1b[2J")
+	screen, err := newVirtualScreen("Terminal", o.Orientation, func(img *image.Paletted) error {
+		return drawInTerminal(output, img, cellWidth, cellHeight, cell)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return screen, screen.Flush()
+}
+
+// drawInTerminal moves the cursor to the top left corner of the terminal and draws the picture in a frame,
+// every character covering a cell of the given size
+func drawInTerminal(output io.Writer, img *image.Paletted, cellWidth, cellHeight int, cell func(img *image.Paletted, x, y int) rune) error {
+	rect := img.Bounds()
+	columns := (rect.Dx() + cellWidth - 1) / cellWidth
+	w := bufio.NewWriter(output)
+	w.WriteString("\x1b[H┌")
+	for x := 0; x < columns; x++ {
+		w.WriteRune('─')
+	}
+	w.WriteString("┐\n")
+	for y := 0; y < rect.Dy(); y += cellHeight {
+		w.WriteRune('│')
+		for x := 0; x < rect.Dx(); x += cellWidth {
+			w.WriteRune(cell(img, x, y))
+		}
+		w.WriteString("│\n")
+	}
+	w.WriteRune('└')
+	for x := 0; x < columns; x++ {
+		w.WriteRune('─')
+	}
+	w.WriteString("┘\n")
+	return w.Flush()
+}
+
+// halfBlockCell returns the character drawing the pixel at (x, y) and the one below it
+func halfBlockCell(img *image.Paletted, x, y int) rune {
+	return halfBlocks[img.ColorIndexAt(x, y)|img.ColorIndexAt(x, y+1)<<1]
+}
+
+// brailleCell returns the braille pattern drawing the 2x4 pixels with the top left corner at (x, y)
+func brailleCell(img *image.Paletted, x, y int) rune {
+	pattern := rune(0x2800)
+	for dy := range brailleDots {
+		for dx, dot := range brailleDots[dy] {
+			if img.ColorIndexAt(x+dx, y+dy) == 1 {
+				pattern |= dot
+			}
+		}
+	}
+	return pattern
+}
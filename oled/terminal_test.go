@@ -0,0 +1,53 @@
+package oled
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTerminalDrawsHalfBlocks(t *testing.T) {
+	var output bytes.Buffer
+	scr, err := Open(&TerminalOpener{Output: &output})
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	output.Reset()
+	scr.Framebuffer().SetPixel(0, 0, true)
+	scr.Framebuffer().SetPixel(1, 1, true)
+	scr.Framebuffer().FillRect(2, 0, 1, 2, true)
+	scr.Flush()
+	rows := strings.Split(output.String(), "\n")
+	if len(rows) != Height/2+3 {
+		t.Fatalf("Unexpected number of rows %d", len(rows))
+	}
+	if !strings.HasPrefix(rows[1], "│▀▄█ ") || len([]rune(rows[1])) != Width+2 {
+		t.Errorf("Unexpected first row %q", rows[1])
+	}
+
+	output.Reset()
+	scr.Flush()
+	if output.Len() != 0 {
+		t.Errorf("Unchanged screen is redrawn")
+	}
+	scr.SetInverted(true)
+	if rows = strings.Split(output.String(), "\n"); !strings.HasPrefix(rows[1], "│▄▀ █") {
+		t.Errorf("Inverted screen is not redrawn, first row %q", rows[1])
+	}
+}
+
+func TestTerminalDrawsBraille(t *testing.T) {
+	var output bytes.Buffer
+	scr, err := Open(&TerminalOpener{Output: &output, Braille: true, Orientation: Orientation{Rotation: 90}})
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	output.Reset()
+	scr.Framebuffer().SetPixel(0, 0, true)
+	scr.Framebuffer().SetPixel(1, 3, true)
+	scr.Flush()
+	rows := strings.Split(output.String(), "\n")
+	if len(rows) != Width/4+3 || !strings.HasPrefix(rows[1], "│⢁⠀") || len([]rune(rows[1])) != Height/2+2 {
+		t.Errorf("Unexpected first row %q of %d", rows[1], len(rows))
+	}
+}
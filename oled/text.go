@@ -0,0 +1,153 @@
+package oled
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// MaxTextScale is the largest supported magnification of text
+const MaxTextScale = 4
+
+// styleFont returns the font used to print text in the given style and the magnification of the text
+// Proportional text without a font is printed in the proportional variant of the built-in font
+func styleFont(style TextStyle) (*Font, int, error) {
+	scale := style.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	if scale < 1 || scale > MaxTextScale {
+		return nil, 0, fmt.Errorf("Text scale should be between 1 and %d", MaxTextScale)
+	}
+	name := style.Font
+	if name == "" && style.Proportional {
+		name = BuiltinProportionalFont
+	}
+	font, err := LookupFont(name)
+	return font, scale, err
+}
+
+// advance returns the distance from the origin of the glyph to the origin of the next one
+// Fixed width text is laid out in the character cells of the font the glyph comes from
+func advance(g *Glyph, from *Font, proportional bool) int {
+	if proportional {
+		return g.Advance
+	}
+	return from.Width
+}
+
+// TextHeight returns the height of a line of text printed in the given style, in pixels
+func TextHeight(style TextStyle) (int, error) {
+	font, scale, err := styleFont(style)
+	if err != nil {
+		return 0, err
+	}
+	return font.Height() * scale, nil
+}
+
+// layoutText passes every character of the text, and every icon written inline as :name:, to place in order
+// along with the byte index where it starts and the distance to the next one, until place returns false
+// Icons keep their own width in fixed width text
+func layoutText(text string, font *Font, scale int, proportional bool, place func(i int, g *Glyph, width int) bool) {
+	for i := 0; i < len(text); {
+		if icon, size := inlineIcon(text[i:]); icon != nil {
+			g := iconGlyph(icon, font)
+			if !place(i, g, g.Advance*scale) {
+				return
+			}
+			i += size
+			continue
+		}
+		ch, size := utf8.DecodeRuneInString(text[i:])
+		g, from := findGlyph(font, ch)
+		if !place(i, g, advance(g, from, proportional)*scale) {
+			return
+		}
+		i += size
+	}
+}
+
+// MeasureText returns the width of a line of text printed in the given style, in pixels
+func MeasureText(text string, style TextStyle) (int, error) {
+	font, scale, err := styleFont(style)
+	if err != nil {
+		return 0, err
+	}
+	width := 0
+	layoutText(text, font, scale, style.Proportional, func(i int, g *Glyph, w int) bool {
+		width += w
+		return true
+	})
+	return width, nil
+}
+
+// FitText returns the longest beginning of the text that fits into the given width when printed in the given style
+func FitText(text string, style TextStyle, width int) (string, error) {
+	font, scale, err := styleFont(style)
+	if err != nil {
+		return "", err
+	}
+	x, fit := 0, text
+	layoutText(text, font, scale, style.Proportional, func(i int, g *Glyph, w int) bool {
+		if x += w; x > width {
+			fit = text[:i]
+			return false
+		}
+		return true
+	})
+	return fit, nil
+}
+
+// DrawText draws a line of text in the given style, the top left corner of the first character being at (x, y)
+// Fixed width text is laid out in character cells of the font, proportional text uses advances of the glyphs
+// Registered icons written as :name: are drawn inline, level with the top of the line
+// The space taken by every character is blanked under the glyph, and text that does not fit is clipped at the edge of the screen
+// Returns the horizontal position right after the text
+func (fb *Framebuffer) DrawText(x, y int, text string, style TextStyle) (int, error) {
+	font, scale, err := styleFont(style)
+	if err != nil {
+		return x, err
+	}
+	baseline := y + font.Ascent*scale
+	width, _ := fb.Size()
+	layoutText(text, font, scale, style.Proportional, func(i int, g *Glyph, w int) bool {
+		if x >= width {
+			return false
+		}
+		fb.drawGlyph(g, scale, x, baseline, x, y, w, font.Height()*scale)
+		x += w
+		return true
+	})
+	return x, nil
+}
+
+// drawGlyph draws the glyph magnified scale times with its origin at (x, baseline) and blanks the rest of the given cell
+// Every pixel is written once, so that redrawing the same text leaves the framebuffer clean
+func (fb *Framebuffer) drawGlyph(g *Glyph, scale int, x, baseline, cellX, cellY, cellWidth, cellHeight int) {
+	left, top := x+g.XOffset*scale, baseline-(g.YOffset+g.Height)*scale
+	right, bottom := left+g.Width*scale, top+g.Height*scale
+	minX, maxX := cellX, cellX+cellWidth
+	if left < minX {
+		minX = left
+	}
+	if right > maxX {
+		maxX = right
+	}
+	minY, maxY := cellY, cellY+cellHeight
+	if top < minY {
+		minY = top
+	}
+	if bottom > maxY {
+		maxY = bottom
+	}
+	for yy := minY; yy < maxY; yy++ {
+		inCellRow := yy >= cellY && yy < cellY+cellHeight
+		for xx := minX; xx < maxX; xx++ {
+			lit := xx >= left && yy >= top && g.Pixel((xx-left)/scale, (yy-top)/scale)
+			if lit {
+				fb.SetPixel(xx, yy, true)
+			} else if inCellRow && xx >= cellX && xx < cellX+cellWidth {
+				fb.SetPixel(xx, yy, false)
+			}
+		}
+	}
+}
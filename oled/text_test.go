@@ -0,0 +1,109 @@
+package oled
+
+import "testing"
+
+func TestMeasureText(t *testing.T) {
+	fixed, err := MeasureText("Hi, il!", TextStyle{})
+	if err != nil {
+		t.Fatalf("Failed to measure: %v", err)
+	}
+	if fixed != 7*6 {
+		t.Errorf("Unexpected fixed width %d", fixed)
+	}
+	proportional, err := MeasureText("Hi, il!", TextStyle{Proportional: true})
+	if err != nil {
+		t.Fatalf("Failed to measure: %v", err)
+	}
+	// H: 5+1, i: 3+1, ",": 3+1, " ": 3, i: 3+1, l: 3+1, !: 1+1
+	if proportional != 27 {
+		t.Errorf("Unexpected proportional width %d", proportional)
+	}
+	if _, err := MeasureText("Hi", TextStyle{Font: "no-such-font"}); err == nil {
+		t.Errorf("Expected an error for an unknown font")
+	}
+}
+
+func TestFitText(t *testing.T) {
+	text := "The quick brown fox jumps over the lazy dog"
+	fixed, _ := FitText(text, TextStyle{}, Width)
+	if fixed != text[:21] {
+		t.Errorf("Unexpected fixed width text %q", fixed)
+	}
+	proportional, _ := FitText(text, TextStyle{Proportional: true}, Width)
+	if len(proportional) <= len(fixed) {
+		t.Errorf("Proportional text %q is not longer than fixed width text %q", proportional, fixed)
+	}
+	width, _ := MeasureText(proportional, TextStyle{Proportional: true})
+	if width > Width {
+		t.Errorf("Proportional text %q is %d pixels wide", proportional, width)
+	}
+}
+
+func TestPrintProportional(t *testing.T) {
+	scr, tr := openRecording(t, SH1106)
+	tr.Reset()
+	scr.PrintStyled(2, 0, "Hi, il!", TextStyle{Proportional: true})
+	scr.Flush()
+	assertGolden(t, "print-proportional", tr.String())
+}
+
+func TestScaledText(t *testing.T) {
+	width, err := MeasureText("Hi", TextStyle{Scale: 2})
+	if err != nil {
+		t.Fatalf("Failed to measure: %v", err)
+	}
+	if width != 2*6*2 {
+		t.Errorf("Unexpected scaled width %d", width)
+	}
+	for _, scale := range []int{0, 1, 2, 4} {
+		height, err := TextHeight(TextStyle{Scale: scale})
+		if err != nil {
+			t.Fatalf("Failed to measure scale %d: %v", scale, err)
+		}
+		if scale == 0 && height != 8 || scale != 0 && height != 8*scale {
+			t.Errorf("Unexpected height %d at scale %d", height, scale)
+		}
+	}
+	if _, err := MeasureText("Hi", TextStyle{Scale: 5}); err == nil {
+		t.Errorf("Expected an error for scale 5")
+	}
+	fitted, _ := FitText("0123456789abcdef", TextStyle{Scale: 4}, Width)
+	if fitted != "01234" {
+		t.Errorf("Unexpected scaled text %q", fitted)
+	}
+}
+
+func TestDigitsFont(t *testing.T) {
+	height, err := TextHeight(TextStyle{Font: BuiltinDigitsFont})
+	if err != nil {
+		t.Fatalf("Digits font is not registered: %v", err)
+	}
+	if height != 16 {
+		t.Errorf("Unexpected digits height %d", height)
+	}
+	var fb Framebuffer
+	fb.DrawText(0, 0, "8", TextStyle{Font: BuiltinDigitsFont})
+	for _, p := range [][2]int{{0, 0}, {9, 0}, {0, 7}, {5, 7}, {9, 15}, {5, 15}} {
+		if !fb.Pixel(p[0], p[1]) {
+			t.Errorf("Pixel %v of 8 is not lit", p)
+		}
+	}
+	for _, p := range [][2]int{{5, 4}, {5, 11}, {10, 0}} {
+		if fb.Pixel(p[0], p[1]) {
+			t.Errorf("Pixel %v of 8 is lit", p)
+		}
+	}
+	fb.DrawText(0, 0, "1", TextStyle{Font: BuiltinDigitsFont})
+	if fb.Pixel(0, 0) || !fb.Pixel(9, 15) {
+		t.Errorf("1 is not drawn over 8")
+	}
+}
+
+func TestPrintScaled(t *testing.T) {
+	scr, tr := openRecording(t, SH1106)
+	tr.Reset()
+	scr.PrintStyled(1, 0, "12:30", TextStyle{Font: BuiltinDigitsFont, Proportional: true})
+	scr.PrintStyled(4, 0, "Hi", TextStyle{Scale: 2})
+	scr.Flush()
+	assertGolden(t, "print-scaled", tr.String())
+}
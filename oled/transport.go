@@ -0,0 +1,124 @@
+package oled
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Transport carries commands and RAM data from the screen to its controller chip
+type Transport interface {
+	// Command sends a sequence of commands to the controller
+	Command(cmds ...byte) error
+	// Data writes bytes to the controller RAM at the current write pointer
+	Data(payload []byte) error
+	// Close releases the underlying device
+	Close() error
+}
+
+// commandDataTransport is implemented by transports that can move the write pointer and write to the controller RAM at once
+type commandDataTransport interface {
+	// CommandData sends a sequence of commands followed by bytes written to the controller RAM
+	CommandData(cmds []byte, payload []byte) error
+}
+
+// TransportOpener allows to open a screen over an arbitrary transport
+type TransportOpener struct {
+	// Transport is the connection to the controller chip
+	Transport Transport
+	// Controller is the controller chip of the screen, SH1106 is used when not set
+	Controller Controller
+	// Orientation tells how the picture is turned, see I2cOpener
+	Orientation Orientation
+	// MaxTransfer is the largest number of bytes written to the controller RAM at once, no limit when not set
+	MaxTransfer int
+}
+
+func (o *TransportOpener) open() (Screen, error) {
+	return newControllerScreen(limitTransfers(o.Transport, o.MaxTransfer), o.Controller, o.Orientation, fmt.Sprintf("%T", o.Transport))
+}
+
+// limitedTransport splits RAM writes into pieces the underlying transport can carry
+type limitedTransport struct {
+	Transport
+	max int
+}
+
+// limitTransfers returns a transport that writes at most max bytes to the controller RAM at once,
+// the transport itself when there is no limit
+func limitTransfers(t Transport, max int) Transport {
+	if max <= 0 {
+		return t
+	}
+	return &limitedTransport{t, max}
+}
+
+// Data writes the payload in as few pieces as the limit allows, the controller moves the write pointer along
+func (t *limitedTransport) Data(payload []byte) error {
+	for len(payload) > t.max {
+		if err := t.Transport.Data(payload[:t.max]); err != nil {
+			return err
+		}
+		payload = payload[t.max:]
+	}
+	return t.Transport.Data(payload)
+}
+
+// Transfer is a single write recorded by RecordingTransport
+type Transfer struct {
+	// Command tells whether Bytes were sent as commands or as RAM data
+	Command bool
+	Bytes   []byte
+}
+
+// RecordingTransport captures the exact byte stream sent to the controller instead of sending it anywhere
+// Can be used for testing
+type RecordingTransport struct {
+	Transfers []Transfer
+	Closed    bool
+}
+
+// Command records a sequence of commands
+func (t *RecordingTransport) Command(cmds ...byte) error {
+	if t.Closed {
+		return ErrorScreenClosed
+	}
+	t.Transfers = append(t.Transfers, Transfer{true, append([]byte{}, cmds...)})
+	return nil
+}
+
+// Data records bytes written to the controller RAM
+func (t *RecordingTransport) Data(payload []byte) error {
+	if t.Closed {
+		return ErrorScreenClosed
+	}
+	t.Transfers = append(t.Transfers, Transfer{false, append([]byte{}, payload...)})
+	return nil
+}
+
+// Close marks the transport as closed
+func (t *RecordingTransport) Close() error {
+	t.Closed = true
+	return nil
+}
+
+// Reset forgets all the recorded transfers
+func (t *RecordingTransport) Reset() {
+	t.Transfers = nil
+}
+
+// String dumps the recorded transfers, one per line, prefixed with C for commands and D for data
+func (t *RecordingTransport) String() string {
+	var sb strings.Builder
+	for _, tr := range t.Transfers {
+		if tr.Command {
+			sb.WriteString("C")
+		} else {
+			sb.WriteString("D")
+		}
+		for _, b := range tr.Bytes {
+			fmt.Fprintf(&sb, " %02x", b)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
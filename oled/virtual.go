@@ -0,0 +1,232 @@
+package oled
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"os"
+)
+
+// virtualScreen draws into a framebuffer like a real screen, and passes the picture to show on every flush
+// Screens that exist only in software, such as the terminal one, are built on it
+type virtualScreen struct {
+	fb          Framebuffer
+	settings    DisplaySettings
+	description string
+	closed      bool
+	changed     bool // settings changed since the last flush
+	show        func(img *image.Paletted) error
+}
+
+// VirtualOpener allows to open a screen that exists only in software and passes its picture to Show on every flush that changes it
+type VirtualOpener struct {
+	// Show receives the picture seen on the screen, see PanelImage
+	Show func(img *image.Paletted)
+	// Orientation tells how the picture is turned, see I2cOpener
+	Orientation Orientation
+}
+
+func (o *VirtualOpener) open() (Screen, error) {
+	screen, err := newVirtualScreen("Virtual screen", o.Orientation, func(img *image.Paletted) error {
+		o.Show(img)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return screen, screen.Flush()
+}
+
+// MirrorOpener opens a screen with another opener and passes its picture to Show on every flush that changes it,
+// which allows to watch a real screen from afar
+type MirrorOpener struct {
+	// Opener opens the screen to mirror
+	Opener Opener
+	// Show receives the picture seen on the screen, see PanelImage
+	Show func(img *image.Paletted)
+}
+
+// mirrorScreen passes the picture of the screen it wraps to show
+type mirrorScreen struct {
+	Screen
+	show func(img *image.Paletted)
+}
+
+func (o *MirrorOpener) open() (Screen, error) {
+	screen, err := o.Opener.open()
+	if err != nil {
+		return nil, err
+	}
+	mirror := &mirrorScreen{screen, o.Show}
+	mirror.mirror()
+	return mirror, nil
+}
+
+// mirror passes the current picture to show
+func (s *mirrorScreen) mirror() {
+	s.show(PanelImage(s.Framebuffer(), s.DisplaySettings()))
+}
+
+func (s *mirrorScreen) Flush() error {
+	changed := false
+	for page := 0; page < Pages; page++ {
+		changed = changed || s.Framebuffer().Dirty(page)
+	}
+	if err := s.Screen.Flush(); err != nil {
+		return err
+	}
+	if changed {
+		s.mirror()
+	}
+	return nil
+}
+
+func (s *mirrorScreen) SetInverted(inverted bool) error {
+	if err := s.Screen.SetInverted(inverted); err != nil {
+		return err
+	}
+	s.mirror()
+	return nil
+}
+
+func (s *mirrorScreen) SetPower(on bool) error {
+	if err := s.Screen.SetPower(on); err != nil {
+		return err
+	}
+	s.mirror()
+	return nil
+}
+
+// newVirtualScreen returns a blank screen in the given orientation that passes its picture to show
+func newVirtualScreen(description string, o Orientation, show func(img *image.Paletted) error) (*virtualScreen, error) {
+	screen := &virtualScreen{description: description, show: show}
+	if err := screen.fb.SetOrientation(o); err != nil {
+		return nil, err
+	}
+	screen.settings = DisplaySettings{Contrast: 0x80, On: true}
+	screen.fb.Invalidate()
+	return screen, nil
+}
+
+// PanelImage returns the picture seen on a panel with the given framebuffer and settings:
+// an inverted panel swaps lit and blank pixels, and a panel that is off shows nothing
+func PanelImage(fb *Framebuffer, settings DisplaySettings) *image.Paletted {
+	img := fb.Image()
+	for i := range img.Pix {
+		if !settings.On {
+			img.Pix[i] = 0
+		} else if settings.Inverted {
+			img.Pix[i] ^= 1
+		}
+	}
+	return img
+}
+
+func (s *virtualScreen) Flush() error {
+	if s.closed {
+		return ErrorScreenClosed
+	}
+	dirty := s.changed
+	s.fb.flush(func(page, x int, data []byte) error {
+		dirty = true
+		return nil
+	})
+	if !dirty {
+		return nil
+	}
+	s.changed = false
+	return s.show(PanelImage(&s.fb, s.settings))
+}
+
+func (s *virtualScreen) Framebuffer() *Framebuffer {
+	return &s.fb
+}
+
+func (s *virtualScreen) Clear() error {
+	if s.closed {
+		return ErrorScreenClosed
+	}
+	s.fb.Clear()
+	return nil
+}
+
+func (s *virtualScreen) Print(line int, offset int, message string) error {
+	return s.PrintStyled(line, offset, message, TextStyle{})
+}
+
+func (s *virtualScreen) PrintStyled(line int, offset int, message string, style TextStyle) error {
+	if s.closed {
+		return ErrorScreenClosed
+	}
+	return s.fb.printStyled(line, offset, message, style)
+}
+
+func (s *virtualScreen) DisplaySignalLevel(line int, offset int, level int) error {
+	if s.closed {
+		return ErrorScreenClosed
+	}
+	s.fb.signalLevel(line, offset, level)
+	return nil
+}
+
+func (s *virtualScreen) DisplayImageFile(filepath string) error {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return s.DisplayImage(file)
+}
+
+func (s *virtualScreen) DisplayImage(reader io.Reader) error {
+	return s.DisplayImageStyled(reader, ImageStyle{})
+}
+
+func (s *virtualScreen) DisplayImageStyled(reader io.Reader, style ImageStyle) error {
+	if s.closed {
+		return ErrorScreenClosed
+	}
+	return s.fb.displayImage(reader, style)
+}
+
+func (s *virtualScreen) SetContrast(contrast int) error {
+	if s.closed {
+		return ErrorScreenClosed
+	}
+	if contrast < 0 || contrast > 255 {
+		return fmt.Errorf("Contrast should be between 0 and 255")
+	}
+	s.settings.Contrast = contrast
+	return nil
+}
+
+func (s *virtualScreen) SetInverted(inverted bool) error {
+	if s.closed {
+		return ErrorScreenClosed
+	}
+	s.changed = s.changed || s.settings.Inverted != inverted
+	s.settings.Inverted = inverted
+	return s.Flush()
+}
+
+func (s *virtualScreen) SetPower(on bool) error {
+	if s.closed {
+		return ErrorScreenClosed
+	}
+	s.changed = s.changed || s.settings.On != on
+	s.settings.On = on
+	return s.Flush()
+}
+
+func (s *virtualScreen) DisplaySettings() DisplaySettings {
+	return s.settings
+}
+
+func (s *virtualScreen) Description() string {
+	return s.description
+}
+
+func (s *virtualScreen) Close() error {
+	s.closed = true
+	return nil
+}
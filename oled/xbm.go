@@ -0,0 +1,77 @@
+package oled
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	image.RegisterFormat("xbm", "#define", decodeXbm, decodeXbmConfig)
+}
+
+var xbmDefine = regexp.MustCompile(`#define\s+\S*?(width|height)\s+(\d+)`)
+var xbmByte = regexp.MustCompile(`0[xX][0-9a-fA-F]{1,2}\b`)
+
+// readXbm returns the size of an XBM image and the C source that follows the size definitions
+func readXbm(reader io.Reader) (width, height int, rest string, err error) {
+	data, err := io.ReadAll(io.LimitReader(reader, 1<<22))
+	if err != nil {
+		return 0, 0, "", err
+	}
+	source := string(data)
+	end := 0
+	for _, match := range xbmDefine.FindAllStringSubmatchIndex(source, -1) {
+		value, _ := strconv.Atoi(source[match[4]:match[5]])
+		if source[match[2]:match[3]] == "width" {
+			width = value
+		} else {
+			height = value
+		}
+		end = match[1]
+	}
+	if width <= 0 || height <= 0 || width*height > 1<<24 {
+		return 0, 0, "", fmt.Errorf("not an XBM image")
+	}
+	return width, height, source[end:], nil
+}
+
+func decodeXbmConfig(reader io.Reader) (image.Config, error) {
+	width, height, _, err := readXbm(reader)
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{ColorModel: color.GrayModel, Width: width, Height: height}, nil
+}
+
+// decodeXbm reads an X11 bitmap, which is a C array of bytes with the leftmost pixel in the least significant bit
+// Set bits are the foreground and come out black
+func decodeXbm(reader io.Reader) (image.Image, error) {
+	width, height, rest, err := readXbm(reader)
+	if err != nil {
+		return nil, err
+	}
+	start := strings.IndexByte(rest, '{')
+	if start < 0 {
+		return nil, fmt.Errorf("XBM bitmap data is missing")
+	}
+	values := xbmByte.FindAllString(rest[start:], -1)
+	rowSize := (width + 7) / 8
+	if len(values) < rowSize*height {
+		return nil, fmt.Errorf("XBM bitmap has %d bytes instead of %d", len(values), rowSize*height)
+	}
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			b, _ := strconv.ParseUint(values[y*rowSize+x/8][2:], 16, 8)
+			if b&(1<<uint(x%8)) == 0 {
+				img.Pix[y*img.Stride+x] = 0xFF
+			}
+		}
+	}
+	return img, nil
+}